@@ -0,0 +1,142 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package nft
+
+import (
+	"context"
+	"fmt"
+)
+
+// probeTable is the name of the table used by the feature probes.
+// The probes are checked and never applied, the table is not created on the system.
+const probeTable = "go_nft_probe"
+
+// SystemCapabilities describes the nftables version and the features supported by the system,
+// both by the `nft` executable and the kernel.
+type SystemCapabilities struct {
+	// Version is the nftables userspace version, e.g. "1.0.9".
+	Version           string
+	JsonSchemaVersion int
+
+	// Destroy is the support of the destroy command, deleting an object only if it exists.
+	Destroy bool
+	// InetNAT is the support of NAT chains in the inet family.
+	InetNAT bool
+	// InetIngress is the support of the ingress hook in the inet family.
+	InetIngress bool
+	// NetdevEgress is the support of the egress hook in the netdev family.
+	NetdevEgress bool
+	// Flowtable is the support of flowtables.
+	Flowtable bool
+	// Synproxy is the support of the synproxy statement.
+	Synproxy bool
+	// Last is the support of the last statement, recording the last time a rule matched.
+	Last bool
+}
+
+type capabilityProbe struct {
+	capability func(c *SystemCapabilities) *bool
+	script     string
+}
+
+var capabilityProbes = []capabilityProbe{
+	{
+		capability: func(c *SystemCapabilities) *bool { return &c.Destroy },
+		script:     "destroy table ip " + probeTable,
+	},
+	{
+		capability: func(c *SystemCapabilities) *bool { return &c.InetNAT },
+		script: "add table inet " + probeTable + "\n" +
+			"add chain inet " + probeTable + " c { type nat hook prerouting priority -100; }",
+	},
+	{
+		capability: func(c *SystemCapabilities) *bool { return &c.InetIngress },
+		script: "add table inet " + probeTable + "\n" +
+			"add chain inet " + probeTable + " c { type filter hook ingress device lo priority 0; }",
+	},
+	{
+		capability: func(c *SystemCapabilities) *bool { return &c.NetdevEgress },
+		script: "add table netdev " + probeTable + "\n" +
+			"add chain netdev " + probeTable + " c { type filter hook egress device lo priority 0; }",
+	},
+	{
+		capability: func(c *SystemCapabilities) *bool { return &c.Flowtable },
+		script: "add table inet " + probeTable + "\n" +
+			"add flowtable inet " + probeTable + " f { hook ingress priority 0; devices = { lo }; }",
+	},
+	{
+		capability: func(c *SystemCapabilities) *bool { return &c.Synproxy },
+		script: "add table ip " + probeTable + "\n" +
+			"add chain ip " + probeTable + " c\n" +
+			"add rule ip " + probeTable + " c tcp dport 80 synproxy mss 1460 wscale 7",
+	},
+	{
+		capability: func(c *SystemCapabilities) *bool { return &c.Last },
+		script: "add table ip " + probeTable + "\n" +
+			"add chain ip " + probeTable + " c\n" +
+			"add rule ip " + probeTable + " c last",
+	},
+}
+
+// Capabilities returns the nftables version and the features supported by the system.
+// The features are probed by checking (dry-running) transactions which use them.
+// The result is detected once and cached for the following calls, while a failure is not cached.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func Capabilities(ctx context.Context) (*SystemCapabilities, error) {
+	return defaultClient.Capabilities(ctx)
+}
+
+// Capabilities returns the nftables version and the features supported by the system.
+// The result is cached per client (see the Capabilities function).
+func (c *Client) Capabilities(ctx context.Context) (*SystemCapabilities, error) {
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+
+	if c.cachedCapabilities != nil {
+		capabilities := *c.cachedCapabilities
+		return &capabilities, nil
+	}
+
+	config, err := c.ReadConfig(ctx, "tables")
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect capabilities: %v", err)
+	}
+	if len(config.Nftables) == 0 || config.Nftables[0].Metainfo == nil {
+		return nil, fmt.Errorf("failed to detect capabilities: missing metainfo")
+	}
+
+	capabilities := &SystemCapabilities{
+		Version:           config.Nftables[0].Metainfo.Version,
+		JsonSchemaVersion: config.Nftables[0].Metainfo.JsonSchemaVersion,
+	}
+	for _, probe := range capabilityProbes {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("failed to detect capabilities: %v", err)
+		}
+		*probe.capability(capabilities) = c.executor.CheckScript(ctx, probe.script) == nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to detect capabilities: %v", err)
+	}
+
+	c.cachedCapabilities = capabilities
+	result := *capabilities
+	return &result, nil
+}
@@ -0,0 +1,61 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package nft
+
+import (
+	"context"
+	"sync"
+
+	nftexec "github.com/networkplumbing/go-nft/nft/exec"
+)
+
+// Client runs the operations of the nft package through the given executor,
+// allowing to use a configured `nft` executable (e.g. its path or a command wrapper).
+// The package level functions run through the default executor (see nftexec.Executor).
+type Client struct {
+	executor *nftexec.Executor
+
+	capabilitiesMu     sync.Mutex
+	cachedCapabilities *SystemCapabilities
+}
+
+var defaultClient = NewClient(&nftexec.Executor{})
+
+// NewClient returns a client which runs the `nft` executable through the given executor.
+func NewClient(executor *nftexec.Executor) *Client {
+	return &Client{executor: executor}
+}
+
+// ReadConfig loads the nftables configuration from the system and
+// returns it as a nftables config structure.
+func (c *Client) ReadConfig(ctx context.Context, filterCommands ...string) (*Config, error) {
+	return c.executor.ReadConfig(ctx, filterCommands...)
+}
+
+// ApplyConfig applies the given nftables config on the system.
+func (c *Client) ApplyConfig(ctx context.Context, config *Config) error {
+	return c.executor.ApplyConfig(ctx, config)
+}
+
+// ApplyConfigEcho applies the given nftables config on the system, echoing
+// back the added elements with their assigned handles
+func (c *Client) ApplyConfigEcho(ctx context.Context, config *Config) (*Config, error) {
+	return c.executor.ApplyConfigEcho(ctx, config)
+}
@@ -24,7 +24,6 @@ import (
 	"time"
 
 	nftconfig "github.com/networkplumbing/go-nft/nft/config"
-	nftexec "github.com/networkplumbing/go-nft/nft/exec"
 )
 
 type Config = nftconfig.Config
@@ -51,7 +50,7 @@ func ReadConfig(filterCommands ...string) (*Config, error) {
 // returns it as a nftables config structure.
 // The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
 func ReadConfigContext(ctx context.Context, filterCommands ...string) (*Config, error) {
-	return nftexec.ReadConfig(ctx, filterCommands...)
+	return defaultClient.ReadConfig(ctx, filterCommands...)
 }
 
 // ApplyConfig applies the given nftables config on the system.
@@ -65,12 +64,12 @@ func ApplyConfig(c *Config) error {
 // ApplyConfigContext applies the given nftables config on the system.
 // The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
 func ApplyConfigContext(ctx context.Context, c *Config) error {
-	return nftexec.ApplyConfig(ctx, c)
+	return defaultClient.ApplyConfig(ctx, c)
 }
 
 // ApplyConfigEcho applies the given nftables config on the system, echoing
 // back the added elements with their assigned handles
 // The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
 func ApplyConfigEcho(ctx context.Context, c *Config) (*Config, error) {
-	return nftexec.ApplyConfigEcho(ctx, c)
+	return defaultClient.ApplyConfigEcho(ctx, c)
 }
@@ -70,6 +70,9 @@ func (c *Config) LookupChain(toFind *schema.Chain) *schema.Chain {
 				if p := toFind.Policy; p != "" {
 					match = match && chain.Policy == p
 				}
+				if co := toFind.Comment; co != "" {
+					match = match && chain.Comment == co
+				}
 				if match {
 					return chain
 				}
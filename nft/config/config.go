@@ -20,6 +20,7 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 
 	"github.com/networkplumbing/go-nft/nft/schema"
@@ -42,13 +43,37 @@ func (c *Config) ToJSON() ([]byte, error) {
 }
 
 // FromJSON decodes the provided JSON-encoded data and populates the nftables config.
+// The data may hold multiple consecutive configs (e.g. the output of multiple nft commands),
+// in which case their entries are concatenated.
 func (c *Config) FromJSON(data []byte) error {
-	if err := json.Unmarshal(data, c); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(c); err != nil {
 		return err
 	}
+	for decoder.More() {
+		next := New()
+		if err := decoder.Decode(next); err != nil {
+			return err
+		}
+		c.Nftables = append(c.Nftables, next.Nftables...)
+	}
 	return nil
 }
 
+// deepCopy returns a copy of the config, which shares no objects with it.
+// Statements which the schema does not model are copied as is (see schema.Statement RowData).
+func (c *Config) deepCopy() (*Config, error) {
+	data, err := c.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	copied := New()
+	if err := copied.FromJSON(data); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
 // FlushRuleset adds a command to the nftables config that erases all the configuration when applied.
 // It is commonly used as the first config instruction, followed by a declarative configuration.
 // When used, any previous configuration is flushed away before adding the new one.
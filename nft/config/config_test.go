@@ -20,7 +20,9 @@
 package config_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	assert "github.com/stretchr/testify/require"
@@ -69,3 +71,67 @@ func TestFlushRuleset(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, string(expected), string(serializedConfig))
 }
+
+func TestReadSetMapAndFlowtable(t *testing.T) {
+	serializedConfig := []byte(`{"nftables":[` +
+		`{"set":{"family":"inet","table":"mytable","name":"myset","handle":2,` +
+		`"type":["ipv4_addr","inet_service"],"flags":["interval"],"elem":["10.0.0.1"]}},` +
+		`{"map":{"family":"inet","table":"mytable","name":"mymap","type":"inet_service","map":"verdict",` +
+		`"elem":[[22,{"accept":null}]]}},` +
+		`{"flowtable":{"family":"inet","table":"mytable","name":"myflowtable","hook":"ingress","prio":0,"dev":"lo"}}` +
+		`]}`)
+
+	config := nftconfig.New()
+	assert.NoError(t, config.FromJSON(serializedConfig))
+	assert.Len(t, config.Nftables, 3)
+
+	set := config.Nftables[0].Set
+	assert.NotNil(t, set)
+	assert.Equal(t, schema.SetType{"ipv4_addr", "inet_service"}, set.Type)
+	assert.Equal(t, []string{schema.SetFlagInterval}, set.Flags)
+	assert.Len(t, set.Elem, 1)
+
+	setMap := config.Nftables[1].Map
+	assert.NotNil(t, setMap)
+	assert.Equal(t, schema.SetType{"inet_service"}, setMap.Type)
+	assert.Equal(t, schema.SetType{"verdict"}, setMap.Map)
+	assert.Equal(t, schema.ExpressionList{
+		schema.NewMapPair(schema.NewNumber(22), schema.Expression{RowData: json.RawMessage(`{"accept":null}`)}),
+	}, setMap.Elem)
+
+	flowtable := config.Nftables[2].Flowtable
+	assert.NotNil(t, flowtable)
+	assert.Equal(t, schema.Devices{"lo"}, flowtable.Dev)
+
+	reserializedConfig, err := config.ToJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(serializedConfig), string(reserializedConfig))
+}
+
+func TestReadMultipleConfigs(t *testing.T) {
+	serializedConfig := []byte(`{"nftables":[{"table":{"family":"ip","name":"mytable"}}]}` + "\n" +
+		`{"nftables":[{"table":{"family":"ip6","name":"mytable"}}]}`)
+
+	config := nftconfig.New()
+	assert.NoError(t, config.FromJSON(serializedConfig))
+
+	expectedConfig := nftconfig.New()
+	expectedConfig.AddTable(&schema.Table{Family: schema.FamilyIP, Name: "mytable"})
+	expectedConfig.AddTable(&schema.Table{Family: schema.FamilyIP6, Name: "mytable"})
+	assert.Equal(t, expectedConfig, config)
+
+	assert.Error(t, config.FromJSON([]byte(`{"nftables":[]} invalid`)))
+}
+
+func TestRulesetRoundTrip(t *testing.T) {
+	// The ruleset has been listed by `nft -j list ruleset` and holds statements which the schema does not model.
+	serializedConfig, err := ioutil.ReadFile("testdata/ruleset.json")
+	assert.NoError(t, err)
+
+	config := nftconfig.New()
+	assert.NoError(t, config.FromJSON(serializedConfig))
+
+	reserializedConfig, err := config.ToJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(serializedConfig), string(reserializedConfig))
+}
@@ -0,0 +1,110 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config
+
+import (
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// AddCtHelper appends the given conntrack helper to the nftable config.
+// The helper is added without an explicit action (`add`).
+func (c *Config) AddCtHelper(helper *schema.CtHelper) {
+	nftable := schema.Nftable{CtHelper: helper}
+	c.Nftables = append(c.Nftables, nftable)
+}
+
+// DeleteCtHelper appends a given conntrack helper to the nftable config
+// with the `delete` action.
+// Attempting to delete a helper which rules reference, results with a failure when the config is applied.
+func (c *Config) DeleteCtHelper(helper *schema.CtHelper) {
+	nftable := schema.Nftable{Delete: &schema.Objects{CtHelper: helper}}
+	c.Nftables = append(c.Nftables, nftable)
+}
+
+// LookupCtHelper searches the configuration for a conntrack helper with the same table and name and returns it.
+// Mutating the returned helper will result in mutating the configuration.
+func (c *Config) LookupCtHelper(toFind *schema.CtHelper) *schema.CtHelper {
+	for _, nftable := range c.Nftables {
+		if h := nftable.CtHelper; h != nil {
+			if h.Family == toFind.Family && h.Table == toFind.Table && h.Name == toFind.Name {
+				return h
+			}
+		}
+	}
+	return nil
+}
+
+// AddCtTimeout appends the given conntrack timeout policy to the nftable config.
+// The policy is added without an explicit action (`add`).
+func (c *Config) AddCtTimeout(timeout *schema.CtTimeout) {
+	nftable := schema.Nftable{CtTimeout: timeout}
+	c.Nftables = append(c.Nftables, nftable)
+}
+
+// DeleteCtTimeout appends a given conntrack timeout policy to the nftable config
+// with the `delete` action.
+// Attempting to delete a policy which rules reference, results with a failure when the config is applied.
+func (c *Config) DeleteCtTimeout(timeout *schema.CtTimeout) {
+	nftable := schema.Nftable{Delete: &schema.Objects{CtTimeout: timeout}}
+	c.Nftables = append(c.Nftables, nftable)
+}
+
+// LookupCtTimeout searches the configuration for a conntrack timeout policy with the same table and name
+// and returns it.
+// Mutating the returned policy will result in mutating the configuration.
+func (c *Config) LookupCtTimeout(toFind *schema.CtTimeout) *schema.CtTimeout {
+	for _, nftable := range c.Nftables {
+		if t := nftable.CtTimeout; t != nil {
+			if t.Family == toFind.Family && t.Table == toFind.Table && t.Name == toFind.Name {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// AddCtExpectation appends the given conntrack expectation to the nftable config.
+// The expectation is added without an explicit action (`add`).
+func (c *Config) AddCtExpectation(expectation *schema.CtExpectation) {
+	nftable := schema.Nftable{CtExpectation: expectation}
+	c.Nftables = append(c.Nftables, nftable)
+}
+
+// DeleteCtExpectation appends a given conntrack expectation to the nftable config
+// with the `delete` action.
+// Attempting to delete an expectation which rules reference, results with a failure when the config is applied.
+func (c *Config) DeleteCtExpectation(expectation *schema.CtExpectation) {
+	nftable := schema.Nftable{Delete: &schema.Objects{CtExpectation: expectation}}
+	c.Nftables = append(c.Nftables, nftable)
+}
+
+// LookupCtExpectation searches the configuration for a conntrack expectation with the same table and name
+// and returns it.
+// Mutating the returned expectation will result in mutating the configuration.
+func (c *Config) LookupCtExpectation(toFind *schema.CtExpectation) *schema.CtExpectation {
+	for _, nftable := range c.Nftables {
+		if e := nftable.CtExpectation; e != nil {
+			if e.Family == toFind.Family && e.Table == toFind.Table && e.Name == toFind.Name {
+				return e
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config_test
+
+import (
+	"fmt"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestCtObjects(t *testing.T) {
+	testCtObjectActions(t)
+	testCtObjectLookup(t)
+	testCtObjectStatements(t)
+}
+
+func testCtObjectActions(t *testing.T) {
+	helper := &schema.CtHelper{
+		Family:   schema.FamilyINET,
+		Table:    tableName,
+		Name:     "ftp-standard",
+		Type:     "ftp",
+		Protocol: schema.CtProtoTCP,
+		L3Proto:  schema.CtL3ProtoINET,
+	}
+	timeout := &schema.CtTimeout{
+		Family:   schema.FamilyIP,
+		Table:    tableName,
+		Name:     "tcp-short",
+		Protocol: schema.CtProtoTCP,
+		L3Proto:  schema.CtL3ProtoIP,
+		Policy:   map[string]int{schema.CtTimeoutStateEstablished: 100, schema.CtTimeoutStateClose: 4},
+	}
+	expectation := &schema.CtExpectation{
+		Family:   schema.FamilyIP,
+		Table:    tableName,
+		Name:     "expect-ftp",
+		L3Proto:  schema.CtL3ProtoIP,
+		Protocol: schema.CtProtoTCP,
+		Dport:    21,
+		Timeout:  300000,
+		Size:     12,
+	}
+
+	expectedHelper := fmt.Sprintf(
+		`{"family":"inet","table":%q,"name":"ftp-standard","type":"ftp","protocol":"tcp","l3proto":"inet"}`, tableName,
+	)
+	expectedTimeout := fmt.Sprintf(
+		`{"family":"ip","table":%q,"name":"tcp-short","protocol":"tcp","l3proto":"ip","policy":{"close":4,"established":100}}`,
+		tableName,
+	)
+	expectedExpectation := fmt.Sprintf(
+		`{"family":"ip","table":%q,"name":"expect-ftp","l3proto":"ip","protocol":"tcp","dport":21,"timeout":300000,"size":12}`,
+		tableName,
+	)
+
+	tests := []struct {
+		name     string
+		add      func(*nft.Config)
+		delete   func(*nft.Config)
+		key      string
+		expected string
+	}{
+		{"ct helper", func(c *nft.Config) { c.AddCtHelper(helper) }, func(c *nft.Config) { c.DeleteCtHelper(helper) },
+			"ct helper", expectedHelper},
+		{"ct timeout", func(c *nft.Config) { c.AddCtTimeout(timeout) }, func(c *nft.Config) { c.DeleteCtTimeout(timeout) },
+			"ct timeout", expectedTimeout},
+		{"ct expectation", func(c *nft.Config) { c.AddCtExpectation(expectation) },
+			func(c *nft.Config) { c.DeleteCtExpectation(expectation) }, "ct expectation", expectedExpectation},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("add %s", tt.name), func(t *testing.T) {
+			config := nft.NewConfig()
+			tt.add(config)
+
+			serializedConfig, err := config.ToJSON()
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf(`{"nftables":[{%q:%s}]}`, tt.key, tt.expected), string(serializedConfig))
+
+			deserializedConfig := nft.NewConfig()
+			assert.NoError(t, deserializedConfig.FromJSON(serializedConfig))
+			assert.Equal(t, config, deserializedConfig)
+		})
+		t.Run(fmt.Sprintf("delete %s", tt.name), func(t *testing.T) {
+			config := nft.NewConfig()
+			tt.delete(config)
+
+			serializedConfig, err := config.ToJSON()
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf(`{"nftables":[{"delete":{%q:%s}}]}`, tt.key, tt.expected), string(serializedConfig))
+		})
+	}
+}
+
+func testCtObjectLookup(t *testing.T) {
+	config := nft.NewConfig()
+	config.AddCtHelper(&schema.CtHelper{Family: schema.FamilyIP, Table: tableName, Name: "ftp-standard", Type: "ftp"})
+	config.AddCtTimeout(&schema.CtTimeout{Family: schema.FamilyIP, Table: tableName, Name: "udp-short"})
+	config.AddCtExpectation(&schema.CtExpectation{Family: schema.FamilyIP, Table: tableName, Name: "expect-ftp"})
+
+	t.Run("Lookup existing ct objects", func(t *testing.T) {
+		helper := config.LookupCtHelper(&schema.CtHelper{Family: schema.FamilyIP, Table: tableName, Name: "ftp-standard"})
+		assert.NotNil(t, helper)
+		assert.Equal(t, "ftp", helper.Type)
+		assert.NotNil(t, config.LookupCtTimeout(&schema.CtTimeout{Family: schema.FamilyIP, Table: tableName, Name: "udp-short"}))
+		assert.NotNil(t, config.LookupCtExpectation(
+			&schema.CtExpectation{Family: schema.FamilyIP, Table: tableName, Name: "expect-ftp"},
+		))
+	})
+
+	t.Run("Lookup missing ct objects", func(t *testing.T) {
+		assert.Nil(t, config.LookupCtHelper(&schema.CtHelper{Family: schema.FamilyIP6, Table: tableName, Name: "ftp-standard"}))
+		assert.Nil(t, config.LookupCtTimeout(&schema.CtTimeout{Family: schema.FamilyIP, Table: tableName, Name: "tcp-short"}))
+		assert.Nil(t, config.LookupCtExpectation(
+			&schema.CtExpectation{Family: schema.FamilyIP, Table: "other-table", Name: "expect-ftp"},
+		))
+	})
+}
+
+func testCtObjectStatements(t *testing.T) {
+	t.Run("Add rule with ct object statements, check serialization", func(t *testing.T) {
+		testSerializationWith(t, ctObjectStatements)
+	})
+	t.Run("Add rule with ct object statements, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, ctObjectStatements)
+	})
+	t.Run("Add rule with ct object statement looked up in a map, check serialization", func(t *testing.T) {
+		testSerializationWith(t, ctObjectMapStatements)
+	})
+	t.Run("Add rule with ct object statement looked up in a map, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, ctObjectMapStatements)
+	})
+}
+
+func ctObjectStatements() ([]schema.Statement, string) {
+	helper := schema.NewString("ftp-standard")
+	timeout := schema.NewString("tcp-short")
+	expectation := schema.NewString("expect-ftp")
+	statements := []schema.Statement{
+		{CtHelper: &helper},
+		{CtTimeout: &timeout},
+		{CtExpectation: &expectation},
+	}
+
+	serializedStatements := `"expr":[{"ct helper":"ftp-standard"},{"ct timeout":"tcp-short"},{"ct expectation":"expect-ftp"}]`
+
+	return statements, serializedStatements
+}
+
+func ctObjectMapStatements() ([]schema.Statement, string) {
+	helpers := schema.Expression{Map: &schema.MapExpr{
+		Key:  schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolTCP, Field: schema.PayloadFieldTCPDPort}},
+		Data: schema.NewString(schema.NewSetReference("helpers")),
+	}}
+	statements := []schema.Statement{{CtHelper: &helpers}}
+
+	serializedStatements := `"expr":[{"ct helper":{"map":{` +
+		`"key":{"payload":{"protocol":"tcp","field":"dport"}},"data":"@helpers"}}}]`
+
+	return statements, serializedStatements
+}
@@ -0,0 +1,393 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// MaxJumpDepth is the size of the kernel jump stack (NFT_JUMP_STACK_SIZE).
+// A chain which is reached from a base chain through this number of nested
+// jump/goto verdicts is rejected by the kernel.
+const MaxJumpDepth = 16
+
+// ChainRef identifies a chain by its family, table and name.
+type ChainRef struct {
+	Family string
+	Table  string
+	Name   string
+}
+
+func (r ChainRef) String() string {
+	return fmt.Sprintf("%s %s %s", r.Family, r.Table, r.Name)
+}
+
+// JumpEdge represents a jump or goto verdict of a rule, from the chain
+// holding the rule to the target chain.
+// Verdicts which are part of a verdict map (vmap) are included as well.
+type JumpEdge struct {
+	From ChainRef
+	To   ChainRef
+	Goto bool
+	Rule *schema.Rule
+}
+
+// JumpGraph is the directed graph of the chains in a configuration and the
+// jump/goto verdicts connecting them.
+// Jumps are resolved in the table of the rule, as nftables does not allow
+// jumping between tables.
+type JumpGraph struct {
+	// Chains lists the chains in the order they appear in the configuration.
+	// Jump targets which are not defined in the configuration are appended at the end.
+	Chains []ChainRef
+	Edges  []JumpEdge
+
+	baseChains map[ChainRef]*schema.Chain
+	defined    map[ChainRef]bool
+	outEdges   map[ChainRef][]int
+}
+
+// JumpGraph builds the jump graph of the chains and rules in the configuration.
+// Only chains and rules without an explicit action are considered.
+// Verdict maps are followed only when defined inline in the rule,
+// references to named maps cannot be resolved.
+func (c *Config) JumpGraph() *JumpGraph {
+	g := &JumpGraph{
+		baseChains: map[ChainRef]*schema.Chain{},
+		defined:    map[ChainRef]bool{},
+		outEdges:   map[ChainRef][]int{},
+	}
+
+	for _, nftable := range c.Nftables {
+		if chain := nftable.Chain; chain != nil {
+			ref := ChainRef{Family: chain.Family, Table: chain.Table, Name: chain.Name}
+			if !g.defined[ref] {
+				g.defined[ref] = true
+				g.Chains = append(g.Chains, ref)
+			}
+			if chain.Hook != "" {
+				g.baseChains[ref] = chain
+			}
+		}
+	}
+
+	for _, nftable := range c.Nftables {
+		if rule := nftable.Rule; rule != nil {
+			from := ChainRef{Family: rule.Family, Table: rule.Table, Name: rule.Chain}
+			for _, statement := range rule.Expr {
+				for _, target := range statementJumpTargets(statement) {
+					to := ChainRef{Family: rule.Family, Table: rule.Table, Name: target.name}
+					g.addEdge(JumpEdge{From: from, To: to, Goto: target.isGoto, Rule: rule})
+				}
+			}
+		}
+	}
+
+	known := map[ChainRef]bool{}
+	for _, ref := range g.Chains {
+		known[ref] = true
+	}
+	for _, edge := range g.Edges {
+		for _, ref := range []ChainRef{edge.From, edge.To} {
+			if !known[ref] {
+				known[ref] = true
+				g.Chains = append(g.Chains, ref)
+			}
+		}
+	}
+
+	return g
+}
+
+// IsBaseChain reports whether the chain is defined as a base chain (attached to a hook).
+func (g *JumpGraph) IsBaseChain(chain ChainRef) bool {
+	_, isBase := g.baseChains[chain]
+	return isBase
+}
+
+// Unreachable returns the regular chains which cannot be reached from any base chain.
+// Such chains are never evaluated by the kernel.
+func (g *JumpGraph) Unreachable() []ChainRef {
+	reachable := map[ChainRef]bool{}
+	var visit func(chain ChainRef)
+	visit = func(chain ChainRef) {
+		if reachable[chain] {
+			return
+		}
+		reachable[chain] = true
+		for _, i := range g.outEdges[chain] {
+			visit(g.Edges[i].To)
+		}
+	}
+	for _, chain := range g.Chains {
+		if g.IsBaseChain(chain) {
+			visit(chain)
+		}
+	}
+
+	var unreachable []ChainRef
+	for _, chain := range g.Chains {
+		if g.defined[chain] && !reachable[chain] {
+			unreachable = append(unreachable, chain)
+		}
+	}
+	return unreachable
+}
+
+// Loops returns the groups of chains which jump to each other in a loop.
+// Each group lists the chains which are part of the loop, in the configuration order.
+// The kernel rejects a configuration which contains a jump loop.
+func (g *JumpGraph) Loops() [][]ChainRef {
+	// Tarjan's strongly connected components algorithm.
+	var (
+		index    = map[ChainRef]int{}
+		lowLink  = map[ChainRef]int{}
+		onStack  = map[ChainRef]bool{}
+		stack    []ChainRef
+		counter  int
+		loopOf   = map[ChainRef]int{}
+		loopsNum int
+	)
+
+	var strongConnect func(chain ChainRef)
+	strongConnect = func(chain ChainRef) {
+		index[chain] = counter
+		lowLink[chain] = counter
+		counter++
+		stack = append(stack, chain)
+		onStack[chain] = true
+
+		selfLoop := false
+		for _, i := range g.outEdges[chain] {
+			to := g.Edges[i].To
+			if to == chain {
+				selfLoop = true
+			}
+			if _, visited := index[to]; !visited {
+				strongConnect(to)
+				if lowLink[to] < lowLink[chain] {
+					lowLink[chain] = lowLink[to]
+				}
+			} else if onStack[to] && index[to] < lowLink[chain] {
+				lowLink[chain] = index[to]
+			}
+		}
+
+		if lowLink[chain] != index[chain] {
+			return
+		}
+		var component []ChainRef
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == chain {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			for _, member := range component {
+				loopOf[member] = loopsNum
+			}
+			loopsNum++
+		}
+	}
+
+	for _, chain := range g.Chains {
+		if _, visited := index[chain]; !visited {
+			strongConnect(chain)
+		}
+	}
+
+	var loops [][]ChainRef
+	loopPosition := map[int]int{}
+	for _, chain := range g.Chains {
+		loopID, inLoop := loopOf[chain]
+		if !inLoop {
+			continue
+		}
+		position, seen := loopPosition[loopID]
+		if !seen {
+			position = len(loops)
+			loopPosition[loopID] = position
+			loops = append(loops, nil)
+		}
+		loops[position] = append(loops[position], chain)
+	}
+	return loops
+}
+
+// DepthExceeded returns the jump paths which reach the kernel jump stack limit (MaxJumpDepth).
+// Each path starts at a base chain and lists the chains along the deepest path found from it.
+// Edges which close a loop are ignored, loops are reported by Loops().
+func (g *JumpGraph) DepthExceeded() [][]ChainRef {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[ChainRef]int{}
+	height := map[ChainRef]int{}
+	next := map[ChainRef]ChainRef{}
+
+	var visit func(chain ChainRef)
+	visit = func(chain ChainRef) {
+		state[chain] = visiting
+		for _, i := range g.outEdges[chain] {
+			to := g.Edges[i].To
+			switch state[to] {
+			case visiting:
+				continue
+			case unvisited:
+				visit(to)
+			}
+			if h := height[to] + 1; h > height[chain] {
+				height[chain] = h
+				next[chain] = to
+			}
+		}
+		state[chain] = visited
+	}
+
+	var paths [][]ChainRef
+	for _, chain := range g.Chains {
+		if !g.IsBaseChain(chain) {
+			continue
+		}
+		if state[chain] == unvisited {
+			visit(chain)
+		}
+		if height[chain] < MaxJumpDepth {
+			continue
+		}
+		path := []ChainRef{chain}
+		for current := chain; height[current] > 0; {
+			current = next[current]
+			path = append(path, current)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// DOT returns the graph in the Graphviz DOT format.
+// Base chains are drawn as boxes labeled with their hook, goto edges are dashed
+// and chains which are not defined in the configuration are drawn dotted.
+func (g *JumpGraph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph nftables {\n")
+	for _, chain := range g.Chains {
+		attributes := []string{"label=" + strconv.Quote(chain.Table+"/"+chain.Name)}
+		if base, isBase := g.baseChains[chain]; isBase {
+			attributes = []string{
+				"label=" + strconv.Quote(fmt.Sprintf("%s/%s\n(%s)", chain.Table, chain.Name, base.Hook)),
+				"shape=box",
+			}
+		} else if !g.defined[chain] {
+			attributes = append(attributes, "style=dotted")
+		}
+		fmt.Fprintf(&sb, "  %s [%s];\n", dotNodeID(chain), strings.Join(attributes, ", "))
+	}
+	for _, edge := range g.Edges {
+		attributes := ""
+		if edge.Goto {
+			attributes = " [style=dashed]"
+		}
+		fmt.Fprintf(&sb, "  %s -> %s%s;\n", dotNodeID(edge.From), dotNodeID(edge.To), attributes)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func dotNodeID(chain ChainRef) string {
+	return strconv.Quote(chain.String())
+}
+
+func (g *JumpGraph) addEdge(edge JumpEdge) {
+	g.Edges = append(g.Edges, edge)
+	g.outEdges[edge.From] = append(g.outEdges[edge.From], len(g.Edges)-1)
+}
+
+type jumpTarget struct {
+	name   string
+	isGoto bool
+}
+
+func statementJumpTargets(statement schema.Statement) []jumpTarget {
+	var targets []jumpTarget
+	if statement.Jump != nil {
+		targets = append(targets, jumpTarget{name: statement.Jump.Target})
+	}
+	if statement.Goto != nil {
+		targets = append(targets, jumpTarget{name: statement.Goto.Target, isGoto: true})
+	}
+	if statement.Vmap != nil {
+		targets = append(targets, verdictMapJumpTargets(statement.Vmap.Data)...)
+	}
+	return targets
+}
+
+// verdictMapJumpTargets collects the jump/goto verdicts found in the data of a verdict map.
+func verdictMapJumpTargets(data schema.Expression) []jumpTarget {
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	var dynamicStruct interface{}
+	if err := json.Unmarshal(rawData, &dynamicStruct); err != nil {
+		return nil
+	}
+
+	var targets []jumpTarget
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]interface{}:
+			for _, verdict := range []string{"jump", "goto"} {
+				if toTarget, ok := v[verdict].(map[string]interface{}); ok {
+					if name, ok := toTarget["target"].(string); ok {
+						targets = append(targets, jumpTarget{name: name, isGoto: verdict == "goto"})
+					}
+				}
+			}
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(v[key])
+			}
+		}
+	}
+	walk(dynamicStruct)
+	return targets
+}
@@ -0,0 +1,198 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/nft"
+	nftconfig "github.com/networkplumbing/go-nft/nft/config"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestJumpGraph(t *testing.T) {
+	testJumpGraphUnreachableChains(t)
+	testJumpGraphLoops(t)
+	testJumpGraphDepth(t)
+	testJumpGraphVerdictMap(t)
+	testJumpGraphDOT(t)
+}
+
+func testJumpGraphUnreachableChains(t *testing.T) {
+	t.Run("Detect chains unreachable from base chains", func(t *testing.T) {
+		config, table := newJumpGraphConfig()
+		base := addBaseChain(config, table, "base")
+		chainA := addRegularChain(config, table, "chain-a")
+		chainB := addRegularChain(config, table, "chain-b")
+		addRegularChain(config, table, "chain-orphan")
+
+		addJumpRule(config, table, base, chainA.Name)
+		addGotoRule(config, table, chainA, chainB.Name)
+
+		graph := config.JumpGraph()
+		assert.Equal(t, []nftconfig.ChainRef{chainRef(table, "chain-orphan")}, graph.Unreachable())
+		assert.True(t, graph.IsBaseChain(chainRef(table, base.Name)))
+		assert.False(t, graph.IsBaseChain(chainRef(table, chainA.Name)))
+		assert.Empty(t, graph.Loops())
+		assert.Empty(t, graph.DepthExceeded())
+	})
+}
+
+func testJumpGraphLoops(t *testing.T) {
+	t.Run("Detect jump loops", func(t *testing.T) {
+		config, table := newJumpGraphConfig()
+		base := addBaseChain(config, table, "base")
+		chainA := addRegularChain(config, table, "chain-a")
+		chainB := addRegularChain(config, table, "chain-b")
+		chainC := addRegularChain(config, table, "chain-c")
+		chainSelf := addRegularChain(config, table, "chain-self")
+
+		addJumpRule(config, table, base, chainA.Name)
+		addJumpRule(config, table, chainA, chainB.Name)
+		addGotoRule(config, table, chainB, chainC.Name)
+		addJumpRule(config, table, chainC, chainA.Name)
+		addJumpRule(config, table, chainSelf, chainSelf.Name)
+
+		expectedLoops := [][]nftconfig.ChainRef{
+			{chainRef(table, chainA.Name), chainRef(table, chainB.Name), chainRef(table, chainC.Name)},
+			{chainRef(table, chainSelf.Name)},
+		}
+		assert.Equal(t, expectedLoops, config.JumpGraph().Loops())
+	})
+}
+
+func testJumpGraphDepth(t *testing.T) {
+	t.Run("Detect jumps beyond the kernel limit", func(t *testing.T) {
+		config, table := newJumpGraphConfig()
+		base := addBaseChain(config, table, "base")
+		expectedPath := []nftconfig.ChainRef{chainRef(table, base.Name)}
+
+		previous := base
+		for i := 0; i < nftconfig.MaxJumpDepth; i++ {
+			chain := addRegularChain(config, table, fmt.Sprintf("chain-%d", i))
+			addJumpRule(config, table, previous, chain.Name)
+			expectedPath = append(expectedPath, chainRef(table, chain.Name))
+			previous = chain
+		}
+
+		assert.Equal(t, [][]nftconfig.ChainRef{expectedPath}, config.JumpGraph().DepthExceeded())
+	})
+
+	t.Run("Jumps within the kernel limit", func(t *testing.T) {
+		config, table := newJumpGraphConfig()
+		base := addBaseChain(config, table, "base")
+
+		previous := base
+		for i := 0; i < nftconfig.MaxJumpDepth-1; i++ {
+			chain := addRegularChain(config, table, fmt.Sprintf("chain-%d", i))
+			addJumpRule(config, table, previous, chain.Name)
+			previous = chain
+		}
+
+		assert.Empty(t, config.JumpGraph().DepthExceeded())
+	})
+}
+
+func testJumpGraphVerdictMap(t *testing.T) {
+	t.Run("Follow verdict map targets", func(t *testing.T) {
+		config, table := newJumpGraphConfig()
+		base := addBaseChain(config, table, "base")
+		chainA := addRegularChain(config, table, "chain-a")
+		chainB := addRegularChain(config, table, "chain-b")
+
+		vmapStatement := schema.Statement{Vmap: &schema.Vmap{
+			Key: schema.Expression{Payload: &schema.Payload{
+				Protocol: schema.PayloadProtocolIP4,
+				Field:    schema.PayloadFieldIP4Protocol,
+			}},
+			Data: schema.Expression{RowData: json.RawMessage(
+				`{"set":[["tcp",{"jump":{"target":"chain-a"}}],["udp",{"goto":{"target":"chain-b"}}],["icmp","accept"]]}`,
+			)},
+		}}
+		config.AddRule(nft.NewRule(table, base, []schema.Statement{vmapStatement}, nil, nil, ""))
+
+		graph := config.JumpGraph()
+		assert.Empty(t, graph.Unreachable())
+		assert.Len(t, graph.Edges, 2)
+		assert.Equal(t, chainRef(table, chainA.Name), graph.Edges[0].To)
+		assert.False(t, graph.Edges[0].Goto)
+		assert.Equal(t, chainRef(table, chainB.Name), graph.Edges[1].To)
+		assert.True(t, graph.Edges[1].Goto)
+	})
+}
+
+func testJumpGraphDOT(t *testing.T) {
+	t.Run("Export the graph in DOT format", func(t *testing.T) {
+		config, table := newJumpGraphConfig()
+		base := addBaseChain(config, table, "base")
+		chainA := addRegularChain(config, table, "chain-a")
+
+		addJumpRule(config, table, base, chainA.Name)
+		addGotoRule(config, table, chainA, "chain-undefined")
+
+		expected := `digraph nftables {
+  "inet test-table base" [label="test-table/base\n(input)", shape=box];
+  "inet test-table chain-a" [label="test-table/chain-a"];
+  "inet test-table chain-undefined" [label="test-table/chain-undefined", style=dotted];
+  "inet test-table base" -> "inet test-table chain-a";
+  "inet test-table chain-a" -> "inet test-table chain-undefined" [style=dashed];
+}
+`
+		assert.Equal(t, expected, config.JumpGraph().DOT())
+	})
+}
+
+func newJumpGraphConfig() (*nft.Config, *schema.Table) {
+	config := nft.NewConfig()
+	table := nft.NewTable(tableName, nft.FamilyINET)
+	config.AddTable(table)
+	return config, table
+}
+
+func addBaseChain(config *nft.Config, table *schema.Table, name string) *schema.Chain {
+	ctype, hook, prio, policy := nft.TypeFilter, nft.HookInput, 0, nft.PolicyAccept
+	chain := nft.NewChain(table, name, &ctype, &hook, &prio, &policy)
+	config.AddChain(chain)
+	return chain
+}
+
+func addRegularChain(config *nft.Config, table *schema.Table, name string) *schema.Chain {
+	chain := nft.NewRegularChain(table, name)
+	config.AddChain(chain)
+	return chain
+}
+
+func addJumpRule(config *nft.Config, table *schema.Table, chain *schema.Chain, target string) {
+	statements := []schema.Statement{{Verdict: schema.Verdict{Jump: &schema.ToTarget{Target: target}}}}
+	config.AddRule(nft.NewRule(table, chain, statements, nil, nil, ""))
+}
+
+func addGotoRule(config *nft.Config, table *schema.Table, chain *schema.Chain, target string) {
+	statements := []schema.Statement{{Verdict: schema.Verdict{Goto: &schema.ToTarget{Target: target}}}}
+	config.AddRule(nft.NewRule(table, chain, statements, nil, nil, ""))
+}
+
+func chainRef(table *schema.Table, name string) nftconfig.ChainRef {
+	return nftconfig.ChainRef{Family: table.Family, Table: table.Name, Name: name}
+}
@@ -0,0 +1,82 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config_test
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestNATFromNetip(t *testing.T) {
+	t.Run("SNAT to an address in an ip table", func(t *testing.T) {
+		snat, err := schema.NewSnatFromNetip(schema.FamilyIP, netip.MustParseAddr("10.0.0.1"), nil)
+		assert.NoError(t, err)
+		assert.NoError(t, snat.Validate(schema.FamilyIP))
+		assertNATJSON(t, `{"addr":"10.0.0.1"}`, snat)
+	})
+
+	t.Run("DNAT to an address and port range in an inet table", func(t *testing.T) {
+		dnat, err := schema.NewDnatFromNetip(
+			schema.FamilyINET, netip.MustParseAddr("fd00::1"), &schema.PortRange{Min: 8000, Max: 8080},
+		)
+		assert.NoError(t, err)
+		assert.NoError(t, dnat.Validate(schema.FamilyINET))
+		assertNATJSON(t, `{"addr":"fd00::1","family":"ip6","port":{"range":[8000,8080]}}`, dnat)
+	})
+
+	t.Run("SNAT to a prefix", func(t *testing.T) {
+		snat, err := schema.NewSnatFromNetipPrefix(
+			schema.FamilyINET, netip.MustParsePrefix("10.0.1.7/24"), &schema.PortRange{Min: 80, Max: 80},
+		)
+		assert.NoError(t, err)
+		assertNATJSON(t, `{"addr":{"prefix":{"addr":"10.0.1.0","len":24}},"family":"ip","port":80,"type_flags":"prefix"}`, snat)
+	})
+
+	t.Run("DNAT to a prefix", func(t *testing.T) {
+		dnat, err := schema.NewDnatFromNetipPrefix(schema.FamilyIP6, netip.MustParsePrefix("fd00::/64"), nil)
+		assert.NoError(t, err)
+		assertNATJSON(t, `{"addr":{"prefix":{"addr":"fd00::","len":64}},"type_flags":"prefix"}`, dnat)
+	})
+
+	t.Run("Address family not matching the table family", func(t *testing.T) {
+		_, err := schema.NewSnatFromNetip(schema.FamilyIP6, netip.MustParseAddr("10.0.0.1"), nil)
+		assert.Error(t, err)
+		_, err = schema.NewDnatFromNetipPrefix(schema.FamilyIP, netip.MustParsePrefix("fd00::/64"), nil)
+		assert.Error(t, err)
+		_, err = schema.NewDnatFromNetip(schema.FamilyBridge, netip.MustParseAddr("10.0.0.1"), nil)
+		assert.Error(t, err)
+		_, err = schema.NewSnatFromNetip(schema.FamilyIP, netip.Addr{}, nil)
+		assert.Error(t, err)
+	})
+}
+
+func assertNATJSON(t *testing.T, expected string, nat interface{}) {
+	data, err := json.Marshal(nat)
+	assert.NoError(t, err)
+	assert.JSONEq(t, expected, string(data))
+}
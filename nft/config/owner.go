@@ -0,0 +1,329 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// Owner identifies the chains and rules which belong to a component,
+// allowing multiple components to share the same ruleset without affecting each other.
+//
+// Ownership is marked in one of two ways:
+// - Comment marker: The owned chains and rules comment is prefixed with the owner marker ("[name]").
+// - Table prefix: All the objects in tables which name starts with the prefix are owned.
+type Owner struct {
+	marker      string
+	tablePrefix string
+}
+
+// NewCommentOwner returns an owner which marks its chains and rules through their comment.
+func NewCommentOwner(name string) *Owner {
+	return &Owner{marker: "[" + name + "]"}
+}
+
+// NewTablePrefixOwner returns an owner of the tables which name starts with the given prefix.
+func NewTablePrefixOwner(prefix string) *Owner {
+	return &Owner{tablePrefix: prefix}
+}
+
+// AddChain tags the chain with the owner marker and appends it to the nftable config.
+func (o *Owner) AddChain(c *Config, chain *schema.Chain) {
+	o.TagChain(chain)
+	c.AddChain(chain)
+}
+
+// AddRule tags the rule with the owner marker and appends it to the nftable config.
+func (o *Owner) AddRule(c *Config, rule *schema.Rule) {
+	o.TagRule(rule)
+	c.AddRule(rule)
+}
+
+// TagChain marks the chain as owned.
+// It has no effect for a table prefix owner or when the chain is already marked.
+func (o *Owner) TagChain(chain *schema.Chain) {
+	chain.Comment = o.tagComment(chain.Comment)
+}
+
+// TagRule marks the rule as owned.
+// It has no effect for a table prefix owner or when the rule is already marked.
+func (o *Owner) TagRule(rule *schema.Rule) {
+	rule.Comment = o.tagComment(rule.Comment)
+}
+
+// OwnsTable reports whether the table is owned.
+// With a comment marker, tables are never owned as they may be shared.
+func (o *Owner) OwnsTable(table *schema.Table) bool {
+	return o.isTablePrefixOwner() && strings.HasPrefix(table.Name, o.tablePrefix)
+}
+
+// OwnsChain reports whether the chain is owned.
+func (o *Owner) OwnsChain(chain *schema.Chain) bool {
+	if o.isTablePrefixOwner() {
+		return strings.HasPrefix(chain.Table, o.tablePrefix)
+	}
+	return o.isTaggedComment(chain.Comment)
+}
+
+// OwnsRule reports whether the rule is owned.
+func (o *Owner) OwnsRule(rule *schema.Rule) bool {
+	if o.isTablePrefixOwner() {
+		return strings.HasPrefix(rule.Table, o.tablePrefix)
+	}
+	return o.isTaggedComment(rule.Comment)
+}
+
+// Owned returns a new configuration with only the owned objects of the given configuration.
+// The returned configuration shares the objects with the given one.
+func (o *Owner) Owned(c *Config) *Config {
+	owned := New()
+	for _, nftable := range c.Nftables {
+		switch {
+		case nftable.Table != nil && o.OwnsTable(nftable.Table),
+			nftable.Chain != nil && o.OwnsChain(nftable.Chain),
+			nftable.Rule != nil && o.OwnsRule(nftable.Rule):
+			owned.Nftables = append(owned.Nftables, nftable)
+		}
+	}
+	return owned
+}
+
+// DeleteOwned returns a configuration which removes all the owned objects in the given
+// configuration, as read from the system (rules are deleted by their handle).
+// Owned tables are deleted, owned chains are flushed and deleted and the owned
+// rules in non-owned chains are deleted.
+func (o *Owner) DeleteOwned(current *Config) *Config {
+	c := New()
+	ownedChains := map[ChainRef]bool{}
+	for _, nftable := range current.Nftables {
+		if chain := nftable.Chain; chain != nil && o.OwnsChain(chain) {
+			ownedChains[ChainRef{Family: chain.Family, Table: chain.Table, Name: chain.Name}] = true
+		}
+	}
+
+	for _, nftable := range current.Nftables {
+		if table := nftable.Table; table != nil && o.OwnsTable(table) {
+			c.DeleteTable(table)
+		}
+	}
+	if o.isTablePrefixOwner() {
+		return c
+	}
+
+	for _, nftable := range current.Nftables {
+		rule := nftable.Rule
+		if rule == nil || !o.OwnsRule(rule) || rule.Handle == nil {
+			continue
+		}
+		if !ownedChains[ChainRef{Family: rule.Family, Table: rule.Table, Name: rule.Chain}] {
+			c.DeleteRule(&schema.Rule{Family: rule.Family, Table: rule.Table, Chain: rule.Chain, Handle: rule.Handle})
+		}
+	}
+	var chains []*schema.Chain
+	for _, nftable := range current.Nftables {
+		if chain := nftable.Chain; chain != nil && o.OwnsChain(chain) {
+			chains = append(chains, &schema.Chain{Family: chain.Family, Table: chain.Table, Name: chain.Name})
+		}
+	}
+	// All owned chains are flushed first, as a chain cannot be deleted while it is a jump target.
+	for _, chain := range chains {
+		c.FlushChain(chain)
+	}
+	for _, chain := range chains {
+		c.DeleteChain(chain)
+	}
+	return c
+}
+
+// ReplaceOwned returns a configuration which removes all the owned objects in the current
+// configuration (see DeleteOwned) and adds the desired configuration instead.
+// The chains and rules of a copy of the desired configuration are tagged as owned,
+// the desired configuration itself is not modified.
+// An error is returned when the desired configuration includes objects outside the ownership scope.
+// With a table prefix, any object outside the owned tables is rejected.
+// With a comment marker, objects which cannot be marked (e.g. sets) are rejected, as well as
+// deleted or flushed tables, chains and rules which are not owned in the current configuration.
+// Flushing the ruleset is never allowed.
+func (o *Owner) ReplaceOwned(current *Config, desired *Config) (*Config, error) {
+	desired, err := desired.deepCopy()
+	if err != nil {
+		return nil, err
+	}
+
+	c := o.DeleteOwned(current)
+	for _, nftable := range desired.Nftables {
+		switch {
+		case nftable.Add != nil:
+			err = o.tagDesired(nftable.Add)
+		case nftable.Delete != nil:
+			err = o.checkRemoved(current, nftable.Delete)
+		case nftable.Flush != nil:
+			err = o.checkRemoved(current, nftable.Flush)
+		case nftable.Metainfo != nil:
+		default:
+			err = o.tagDesired(nftableObjects(nftable))
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.Nftables = append(c.Nftables, nftable)
+	}
+	return c, nil
+}
+
+// tagDesired tags the added chains and rules and checks all the added objects are owned.
+func (o *Owner) tagDesired(objects *schema.Objects) error {
+	switch {
+	case objects.Table != nil:
+		if o.isTablePrefixOwner() && !o.OwnsTable(objects.Table) {
+			return fmt.Errorf("table %q is not owned", objects.Table.Name)
+		}
+		return nil
+	case objects.Chain != nil:
+		o.TagChain(objects.Chain)
+		if !o.OwnsChain(objects.Chain) {
+			return fmt.Errorf("chain %q in table %q is not owned", objects.Chain.Name, objects.Chain.Table)
+		}
+		return nil
+	case objects.Rule != nil:
+		o.TagRule(objects.Rule)
+		if !o.OwnsRule(objects.Rule) {
+			return fmt.Errorf("rule in table %q is not owned", objects.Rule.Table)
+		}
+		return nil
+	}
+	return o.checkOtherObjects(objects)
+}
+
+// checkRemoved checks the deleted or flushed objects are owned, as they are in the current configuration.
+func (o *Owner) checkRemoved(current *Config, objects *schema.Objects) error {
+	switch {
+	case objects.Ruleset:
+		return fmt.Errorf("flushing the ruleset is not allowed")
+	case objects.Table != nil:
+		if !o.OwnsTable(objects.Table) {
+			return fmt.Errorf("table %q is not owned", objects.Table.Name)
+		}
+		return nil
+	case objects.Chain != nil:
+		chain := objects.Chain
+		if !o.OwnsChain(chain) {
+			currentChain := current.LookupChain(&schema.Chain{Family: chain.Family, Table: chain.Table, Name: chain.Name})
+			if currentChain == nil || !o.OwnsChain(currentChain) {
+				return fmt.Errorf("chain %q in table %q is not owned", chain.Name, chain.Table)
+			}
+		}
+		return nil
+	case objects.Rule != nil:
+		rule := objects.Rule
+		if !o.OwnsRule(rule) {
+			currentRule := lookupRuleByHandle(current, rule)
+			if currentRule == nil || !o.OwnsRule(currentRule) {
+				return fmt.Errorf("rule in chain %q of table %q is not owned", rule.Chain, rule.Table)
+			}
+		}
+		return nil
+	}
+	return o.checkOtherObjects(objects)
+}
+
+// checkOtherObjects checks the objects which have no owner marker (e.g. sets, maps and stateful objects)
+// are in owned tables.
+// With a comment marker, such objects cannot be owned.
+func (o *Owner) checkOtherObjects(objects *schema.Objects) error {
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return err
+	}
+	var dynamicStructure map[string]json.RawMessage
+	if err := json.Unmarshal(data, &dynamicStructure); err != nil {
+		return err
+	}
+	for kind, objectData := range dynamicStructure {
+		var object struct {
+			Table string `json:"table"`
+			Name  string `json:"name"`
+		}
+		if err := json.Unmarshal(objectData, &object); err != nil {
+			return err
+		}
+		if !o.isTablePrefixOwner() || !strings.HasPrefix(object.Table, o.tablePrefix) {
+			return fmt.Errorf("%s %q in table %q is not owned", kind, object.Name, object.Table)
+		}
+	}
+	return nil
+}
+
+// nftableObjects returns the objects of an entry without an explicit action.
+func nftableObjects(nftable schema.Nftable) *schema.Objects {
+	return &schema.Objects{
+		Table:         nftable.Table,
+		Chain:         nftable.Chain,
+		Rule:          nftable.Rule,
+		Set:           nftable.Set,
+		Map:           nftable.Map,
+		Flowtable:     nftable.Flowtable,
+		Counter:       nftable.Counter,
+		Quota:         nftable.Quota,
+		CtHelper:      nftable.CtHelper,
+		CtTimeout:     nftable.CtTimeout,
+		CtExpectation: nftable.CtExpectation,
+		Synproxy:      nftable.Synproxy,
+		Secmark:       nftable.Secmark,
+	}
+}
+
+func lookupRuleByHandle(c *Config, toFind *schema.Rule) *schema.Rule {
+	if toFind.Handle == nil {
+		return nil
+	}
+	for _, nftable := range c.Nftables {
+		if rule := nftable.Rule; rule != nil && rule.Handle != nil {
+			match := rule.Family == toFind.Family && rule.Table == toFind.Table && rule.Chain == toFind.Chain
+			if match && *rule.Handle == *toFind.Handle {
+				return rule
+			}
+		}
+	}
+	return nil
+}
+
+func (o *Owner) isTablePrefixOwner() bool {
+	return o.marker == ""
+}
+
+func (o *Owner) tagComment(comment string) string {
+	switch {
+	case o.isTablePrefixOwner(), o.isTaggedComment(comment):
+		return comment
+	case comment == "":
+		return o.marker
+	default:
+		return o.marker + " " + comment
+	}
+}
+
+func (o *Owner) isTaggedComment(comment string) bool {
+	return comment == o.marker || strings.HasPrefix(comment, o.marker+" ")
+}
@@ -0,0 +1,245 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/nft"
+	nftconfig "github.com/networkplumbing/go-nft/nft/config"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestOwner(t *testing.T) {
+	testCommentOwnerTagging(t)
+	testCommentOwnerDeleteOwned(t)
+	testCommentOwnerReplaceOwned(t)
+	testTablePrefixOwner(t)
+}
+
+func testCommentOwnerTagging(t *testing.T) {
+	owner := nftconfig.NewCommentOwner("comp-a")
+	table := nft.NewTable(tableName, nft.FamilyIP)
+
+	t.Run("Tag chains and rules with the owner marker", func(t *testing.T) {
+		config := nft.NewConfig()
+		chain := nft.NewRegularChain(table, chainName)
+		owner.AddChain(config, chain)
+		rule := nft.NewRule(table, chain, nil, nil, nil, "my comment")
+		owner.AddRule(config, rule)
+
+		assert.Equal(t, "[comp-a]", chain.Comment)
+		assert.Equal(t, "[comp-a] my comment", rule.Comment)
+
+		owner.TagRule(rule)
+		assert.Equal(t, "[comp-a] my comment", rule.Comment, "Tagging twice has no effect")
+	})
+
+	t.Run("Filter the owned objects", func(t *testing.T) {
+		config := nft.NewConfig()
+		config.AddTable(table)
+		ownedChain := nft.NewRegularChain(table, "owned-chain")
+		owner.AddChain(config, ownedChain)
+		config.AddChain(nft.NewRegularChain(table, "foreign-chain"))
+		ownedRule := nft.NewRule(table, ownedChain, nil, nil, nil, "")
+		owner.AddRule(config, ownedRule)
+		config.AddRule(nft.NewRule(table, ownedChain, nil, nil, nil, "[comp-b] foreign"))
+		config.AddRule(nft.NewRule(table, ownedChain, nil, nil, nil, "[comp-a]suffix"))
+
+		expected := nft.NewConfig()
+		expected.AddChain(ownedChain)
+		expected.AddRule(ownedRule)
+		assert.Equal(t, expected, owner.Owned(config))
+	})
+}
+
+func testCommentOwnerDeleteOwned(t *testing.T) {
+	owner := nftconfig.NewCommentOwner("comp-a")
+	table := nft.NewTable(tableName, nft.FamilyIP)
+
+	t.Run("Delete the owned objects", func(t *testing.T) {
+		current := nft.NewConfig()
+		current.AddTable(table)
+		ownedChain := nft.NewRegularChain(table, "owned-chain")
+		owner.TagChain(ownedChain)
+		current.AddChain(ownedChain)
+		foreignChain := nft.NewRegularChain(table, "foreign-chain")
+		current.AddChain(foreignChain)
+
+		handles := []int{1, 2, 3}
+		current.AddRule(nft.NewRule(table, ownedChain, nil, &handles[0], nil, "[comp-a]"))
+		current.AddRule(nft.NewRule(table, foreignChain, nil, &handles[1], nil, "[comp-a] jump"))
+		current.AddRule(nft.NewRule(table, foreignChain, nil, &handles[2], nil, "foreign"))
+
+		expected := nft.NewConfig()
+		expected.DeleteRule(&schema.Rule{Family: table.Family, Table: table.Name, Chain: foreignChain.Name, Handle: &handles[1]})
+		chainRef := nft.NewRegularChain(table, ownedChain.Name)
+		expected.FlushChain(chainRef)
+		expected.DeleteChain(chainRef)
+
+		assert.Equal(t, expected, owner.DeleteOwned(current))
+	})
+}
+
+func testCommentOwnerReplaceOwned(t *testing.T) {
+	owner := nftconfig.NewCommentOwner("comp-a")
+	table := nft.NewTable(tableName, nft.FamilyIP)
+
+	t.Run("Replace the owned objects", func(t *testing.T) {
+		current := nft.NewConfig()
+		current.AddTable(table)
+		ownedChain := nft.NewRegularChain(table, chainName)
+		owner.TagChain(ownedChain)
+		current.AddChain(ownedChain)
+
+		desired := nft.NewConfig()
+		desired.AddTable(table)
+		desiredChain := nft.NewRegularChain(table, chainName)
+		desired.AddChain(desiredChain)
+		desired.AddRule(nft.NewRule(table, desiredChain, []schema.Statement{
+			{RowData: json.RawMessage(`{"log":{"prefix":"new "}}`)},
+			{Verdict: schema.Accept()},
+		}, nil, nil, "new"))
+
+		replaceConfig, err := owner.ReplaceOwned(current, desired)
+		assert.NoError(t, err)
+
+		serializedConfig, err := replaceConfig.ToJSON()
+		assert.NoError(t, err)
+		chainArgs := `"family":"ip","table":"test-table","name":"test-chain"`
+		expected := `{"nftables":[` +
+			`{"flush":{"chain":{` + chainArgs + `}}},` +
+			`{"delete":{"chain":{` + chainArgs + `}}},` +
+			`{"table":{"family":"ip","name":"test-table"}},` +
+			`{"chain":{` + chainArgs + `,"comment":"[comp-a]"}},` +
+			`{"rule":{"family":"ip","table":"test-table","chain":"test-chain",` +
+			`"expr":[{"log":{"prefix":"new "}},{"accept":null}],"comment":"[comp-a] new"}}` +
+			`]}`
+		assert.Equal(t, expected, string(serializedConfig))
+		assert.Empty(t, desiredChain.Comment, "The desired configuration is not modified")
+	})
+
+	t.Run("Replace with removal of owned objects", func(t *testing.T) {
+		handle := 5
+		current := nft.NewConfig()
+		ownedChain := nft.NewRegularChain(table, chainName)
+		owner.TagChain(ownedChain)
+		current.AddChain(ownedChain)
+		current.AddRule(nft.NewRule(table, ownedChain, nil, &handle, nil, "[comp-a]"))
+
+		desired := nft.NewConfig()
+		desired.FlushChain(nft.NewRegularChain(table, chainName))
+		desired.DeleteRule(nft.NewRule(table, ownedChain, nil, &handle, nil, ""))
+
+		_, err := owner.ReplaceOwned(current, desired)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Replace with removal of objects which are not owned fails", func(t *testing.T) {
+		handle := 5
+		current := nft.NewConfig()
+		foreignChain := nft.NewRegularChain(table, chainName)
+		foreignChain.Comment = "[comp-b]"
+		current.AddChain(foreignChain)
+		current.AddRule(nft.NewRule(table, foreignChain, nil, &handle, nil, "[comp-b]"))
+
+		for name, removal := range map[string]func(*nft.Config){
+			"delete chain":  func(c *nft.Config) { c.DeleteChain(nft.NewRegularChain(table, chainName)) },
+			"flush chain":   func(c *nft.Config) { c.FlushChain(nft.NewRegularChain(table, chainName)) },
+			"delete rule":   func(c *nft.Config) { c.DeleteRule(nft.NewRule(table, foreignChain, nil, &handle, nil, "")) },
+			"delete table":  func(c *nft.Config) { c.DeleteTable(table) },
+			"flush ruleset": func(c *nft.Config) { c.FlushRuleset() },
+		} {
+			desired := nft.NewConfig()
+			removal(desired)
+			_, err := owner.ReplaceOwned(current, desired)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("Replace with objects which cannot be marked fails", func(t *testing.T) {
+		desired := nft.NewConfig()
+		desired.Nftables = append(desired.Nftables, schema.Nftable{Set: &schema.Set{
+			Family: table.Family, Table: table.Name, Name: "myset", Type: schema.SetType{"ipv4_addr"},
+		}})
+		_, err := owner.ReplaceOwned(nft.NewConfig(), desired)
+		assert.Error(t, err)
+	})
+}
+
+func testTablePrefixOwner(t *testing.T) {
+	owner := nftconfig.NewTablePrefixOwner("comp-a-")
+	ownedTable := nft.NewTable("comp-a-filter", nft.FamilyINET)
+	foreignTable := nft.NewTable("comp-b-filter", nft.FamilyINET)
+
+	current := nft.NewConfig()
+	current.AddTable(ownedTable)
+	current.AddTable(foreignTable)
+	ownedChain := nft.NewRegularChain(ownedTable, chainName)
+	current.AddChain(ownedChain)
+	current.AddChain(nft.NewRegularChain(foreignTable, chainName))
+
+	t.Run("Filter the objects of owned tables", func(t *testing.T) {
+		expected := nft.NewConfig()
+		expected.AddTable(ownedTable)
+		expected.AddChain(ownedChain)
+		assert.Equal(t, expected, owner.Owned(current))
+		assert.Empty(t, ownedChain.Comment, "Table prefix owner does not tag objects")
+	})
+
+	t.Run("Delete the owned tables", func(t *testing.T) {
+		expected := nft.NewConfig()
+		expected.DeleteTable(ownedTable)
+		assert.Equal(t, expected, owner.DeleteOwned(current))
+	})
+
+	t.Run("Replace with objects outside the owned tables fails", func(t *testing.T) {
+		desired := nft.NewConfig()
+		desired.AddTable(foreignTable)
+		_, err := owner.ReplaceOwned(current, desired)
+		assert.Error(t, err)
+
+		desired = nft.NewConfig()
+		desired.DeleteChain(nft.NewRegularChain(foreignTable, chainName))
+		_, err = owner.ReplaceOwned(current, desired)
+		assert.Error(t, err)
+
+		desired = nft.NewConfig()
+		desired.Nftables = append(desired.Nftables, schema.Nftable{Add: &schema.Objects{Counter: &schema.NamedCounter{
+			Family: foreignTable.Family, Table: foreignTable.Name, Name: "mycounter",
+		}}})
+		_, err = owner.ReplaceOwned(current, desired)
+		assert.Error(t, err)
+	})
+
+	t.Run("Replace with objects in the owned tables", func(t *testing.T) {
+		desired := nft.NewConfig()
+		desired.AddTable(ownedTable)
+		desired.Nftables = append(desired.Nftables, schema.Nftable{Set: &schema.Set{
+			Family: ownedTable.Family, Table: ownedTable.Name, Name: "myset", Type: schema.SetType{"ipv4_addr"},
+		}})
+		desired.FlushChain(ownedChain)
+		_, err := owner.ReplaceOwned(current, desired)
+		assert.NoError(t, err)
+	})
+}
@@ -22,6 +22,7 @@ package config_test
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"testing"
 
 	assert "github.com/stretchr/testify/require"
@@ -43,6 +44,15 @@ func TestRule(t *testing.T) {
 	testDeleteRule(t)
 
 	testAddRuleWithRowExpression(t)
+	testAddRuleWithMetaExpression(t)
+	testAddRuleWithPayloadExpressions(t)
+	testAddRuleWithSetExpressions(t)
+	testAddRuleWithBinaryOpExpressions(t)
+	testAddRuleWithHeaderOptionExpressions(t)
+	testAddRuleWithLoadBalancingExpressions(t)
+	testAddRuleWithMangle(t)
+	testAddRuleWithPacketForwarding(t)
+	testAddRuleWithSetStatements(t)
 	testAddRuleWithCounter(t)
 	testAddRuleWithNAT(t)
 
@@ -87,6 +97,145 @@ func testAddRuleWithRowExpression(t *testing.T) {
 	})
 }
 
+func testAddRuleWithMetaExpression(t *testing.T) {
+	t.Run("Add rule with a meta expression, check serialization", func(t *testing.T) {
+		testSerializationWith(t, matchMetaIifnameStatements)
+	})
+	t.Run("Add rule with a meta expression, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, matchMetaIifnameStatements)
+	})
+}
+
+func testAddRuleWithPayloadExpressions(t *testing.T) {
+	t.Run("Add rule with payload expressions, check serialization", func(t *testing.T) {
+		testSerializationWith(t, matchPayloadStatements)
+	})
+	t.Run("Add rule with payload expressions, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, matchPayloadStatements)
+	})
+	t.Run("Validate payload expressions", func(t *testing.T) {
+		assert.NoError(t, schema.Payload{Protocol: schema.PayloadProtocolVLAN, Field: schema.PayloadFieldVLANId}.Validate())
+		assert.NoError(t, schema.NewRawPayload(schema.PayloadBaseNH, 0, 4).Validate())
+		assert.Error(t, schema.Payload{Protocol: schema.PayloadProtocolTCP, Field: "saddr"}.Validate())
+		assert.Error(t, schema.Payload{Protocol: "tcpx", Field: schema.PayloadFieldTCPDPort}.Validate())
+		assert.Error(t, schema.NewRawPayload("xx", 0, 4).Validate())
+		assert.Error(t, schema.NewRawPayload(schema.PayloadBaseTH, 0, 0).Validate())
+	})
+}
+
+func testAddRuleWithSetExpressions(t *testing.T) {
+	t.Run("Add rule with prefix, range, concatenation and set expressions, check serialization", func(t *testing.T) {
+		testSerializationWith(t, matchSetExpressionsStatements)
+	})
+	t.Run("Add rule with prefix, range, concatenation and set expressions, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, matchSetExpressionsStatements)
+	})
+	t.Run("Single expression set is decoded as a set", func(t *testing.T) {
+		var expression schema.Expression
+		assert.NoError(t, json.Unmarshal([]byte(`{"set":22}`), &expression))
+		assert.Equal(t, schema.NewSet(schema.NewNumber(22)), expression)
+	})
+}
+
+func testAddRuleWithBinaryOpExpressions(t *testing.T) {
+	t.Run("Add rule with binary operation expressions, check serialization", func(t *testing.T) {
+		testSerializationWith(t, matchBinaryOpStatements)
+	})
+	t.Run("Add rule with binary operation expressions, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, matchBinaryOpStatements)
+	})
+}
+
+func testAddRuleWithHeaderOptionExpressions(t *testing.T) {
+	t.Run("Add rule with extension header and option expressions, check serialization", func(t *testing.T) {
+		testSerializationWith(t, matchHeaderOptionStatements)
+	})
+	t.Run("Add rule with extension header and option expressions, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, matchHeaderOptionStatements)
+	})
+}
+
+func testAddRuleWithLoadBalancingExpressions(t *testing.T) {
+	t.Run("Add rule with load balancing expressions, check serialization", func(t *testing.T) {
+		testSerializationWith(t, loadBalancingStatements)
+	})
+	t.Run("Add rule with load balancing expressions, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, loadBalancingStatements)
+	})
+	t.Run("Weighted DNAT requires valid endpoints", func(t *testing.T) {
+		_, err := schema.NewWeightedDnat(schema.FamilyIP, schema.NumgenModeInc)
+		assert.Error(t, err)
+		_, err = schema.NewWeightedDnat(schema.FamilyIP, schema.NumgenModeInc, schema.DnatEndpoint{Addr: "10.0.0.1", Weight: 0})
+		assert.Error(t, err)
+		_, err = schema.NewWeightedDnat(schema.FamilyIP, "hash", schema.DnatEndpoint{Addr: "10.0.0.1", Weight: 1})
+		assert.Error(t, err)
+	})
+	t.Run("Weighted DNAT endpoints must match the table family", func(t *testing.T) {
+		_, err := schema.NewWeightedDnat(schema.FamilyIP6, schema.NumgenModeInc, schema.DnatEndpoint{Addr: "10.0.0.1", Weight: 1})
+		assert.Error(t, err)
+		_, err = schema.NewWeightedDnat(schema.FamilyINET, schema.NumgenModeInc,
+			schema.DnatEndpoint{Addr: "10.0.0.1", Weight: 1},
+			schema.DnatEndpoint{Addr: "fd00::1", Weight: 1},
+		)
+		assert.Error(t, err)
+	})
+	t.Run("Weighted DNAT in an inet table", func(t *testing.T) {
+		dnat, err := schema.NewWeightedDnat(schema.FamilyINET, schema.NumgenModeRandom,
+			schema.DnatEndpoint{Addr: "fd00::1", Weight: 1},
+			schema.DnatEndpoint{Addr: "fd00::2", Weight: 1},
+		)
+		assert.NoError(t, err)
+		assert.NotNil(t, dnat.Family)
+		assert.Equal(t, schema.FamilyIP6, *dnat.Family)
+		assert.NoError(t, dnat.Validate(schema.FamilyINET))
+		assert.Equal(t, schema.NumgenModeRandom, dnat.Addr.Map.Key.Numgen.Mode)
+	})
+}
+
+func testAddRuleWithMangle(t *testing.T) {
+	t.Run("Add rule with mangle statements, check serialization", func(t *testing.T) {
+		testSerializationWith(t, mangleStatements)
+	})
+	t.Run("Add rule with mangle statements, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, mangleStatements)
+	})
+	t.Run("Lookup a rule with mangle statements read from JSON", func(t *testing.T) {
+		table := nft.NewTable(tableName, nft.FamilyIP)
+		chain := nft.NewRegularChain(table, chainName)
+
+		statements, serializedStatements := mangleStatements()
+		rule := nft.NewRule(table, chain, statements, nil, nil, "mycomment")
+
+		config := nft.NewConfig()
+		serializedRule := fmt.Sprintf(`{"family":%q,"table":%q,"chain":%q,%s,"comment":"mycomment"}`,
+			table.Family, table.Name, chain.Name, serializedStatements)
+		assert.NoError(t, config.FromJSON([]byte(fmt.Sprintf(`{"nftables":[{"rule":%s}]}`, serializedRule))))
+
+		assert.Len(t, config.LookupRule(rule), 1)
+
+		rule.Expr[0].Mangle.Value = schema.NewNumber(2)
+		assert.Empty(t, config.LookupRule(rule))
+	})
+}
+
+func testAddRuleWithPacketForwarding(t *testing.T) {
+	t.Run("Add rule with tproxy, queue, dup and fwd statements, check serialization", func(t *testing.T) {
+		testSerializationWith(t, packetForwardingStatements)
+	})
+	t.Run("Add rule with tproxy, queue, dup and fwd statements, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, packetForwardingStatements)
+	})
+}
+
+func testAddRuleWithSetStatements(t *testing.T) {
+	t.Run("Add rule with set statements, check serialization", func(t *testing.T) {
+		testSerializationWith(t, setStatements)
+	})
+	t.Run("Add rule with set statements, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, setStatements)
+	})
+}
+
 func testAddRuleWithMatchAndVerdict(t *testing.T) {
 	const comment = "mycomment"
 
@@ -211,6 +360,24 @@ func matchWithRowExpression() ([]schema.Statement, string) {
 	return statements, serializedStatements
 }
 
+func matchMetaIifnameStatements() ([]schema.Statement, string) {
+	ifaceName := "nic0"
+	match := schema.Statement{
+		Match: &schema.Match{
+			Op:    schema.OperEQ,
+			Left:  schema.Expression{Meta: &schema.Meta{Key: schema.MetaKeyIifName}},
+			Right: schema.Expression{String: &ifaceName},
+		},
+	}
+
+	statements := []schema.Statement{match}
+
+	expectedMatch := fmt.Sprintf(`"match":{"op":"==","left":{"meta":{"key":"iifname"}},"right":%q}`, ifaceName)
+	serializedStatements := fmt.Sprintf(`"expr":[{%s}]`, expectedMatch)
+
+	return statements, serializedStatements
+}
+
 func testRuleLookup(t *testing.T) {
 	config := nft.NewConfig()
 	table_br := nft.NewTable("table-br", nft.FamilyBridge)
@@ -316,6 +483,28 @@ func testAddRuleWithCounter(t *testing.T) {
 
 		assert.Equal(t, expectedConfig, &deserializedConfig)
 	})
+
+	t.Run("Add rule with named counters, check serialization", func(t *testing.T) {
+		testSerializationWith(t, namedCounterStatements)
+	})
+	t.Run("Add rule with named counters, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, namedCounterStatements)
+	})
+}
+
+func namedCounterStatements() ([]schema.Statement, string) {
+	statements := []schema.Statement{
+		{Counter: &schema.Counter{Name: "cnt"}},
+		{Counter: &schema.Counter{Ref: &schema.Expression{Map: &schema.MapExpr{
+			Key:  schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolTCP, Field: schema.PayloadFieldTCPDPort}},
+			Data: schema.NewString(schema.NewSetReference("cnt")),
+		}}}},
+	}
+
+	serializedStatements := `"expr":[{"counter":"cnt"},` +
+		`{"counter":{"map":{"key":{"payload":{"protocol":"tcp","field":"dport"}},"data":"@cnt"}}}]`
+
+	return statements, serializedStatements
 }
 
 func counterStatements() ([]schema.Statement, string) {
@@ -341,15 +530,248 @@ func testAddRuleWithNAT(t *testing.T) {
 		{"snat", sNATStatements},
 		{"masquerade", masqueradeStatements},
 		{"redirect", redirectStatements},
+		{"nat type flags", natTypeFlagsStatements},
 	}
 	for _, tt := range tableTests {
 		t.Run(fmt.Sprintf("Add rule with %s, check serialization", tt.typeName), func(t *testing.T) {
-			testSerializationWith(t, dNATStatements)
+			testSerializationWith(t, tt.createStatements)
 		})
 		t.Run(fmt.Sprintf("Add rule with %s, check deserialization", tt.typeName), func(t *testing.T) {
-			testDeserializationWith(t, dNATStatements)
+			testDeserializationWith(t, tt.createStatements)
 		})
 	}
+
+	t.Run("NAT family is validated against the table family", func(t *testing.T) {
+		familyIP4, familyARP := schema.FamilyIP, schema.FamilyARP
+		address := schema.NewString("10.0.0.1")
+
+		assert.NoError(t, (&schema.Snat{Addr: &address}).Validate(schema.FamilyIP))
+		assert.NoError(t, (&schema.Snat{Addr: &address, Family: &familyIP4}).Validate(schema.FamilyINET))
+		assert.Error(t, (&schema.Snat{Addr: &address}).Validate(schema.FamilyINET))
+		assert.Error(t, (&schema.Dnat{Addr: &address, Family: &familyIP4}).Validate(schema.FamilyIP6))
+		assert.Error(t, (&schema.Dnat{Addr: &address, Family: &familyARP}).Validate(schema.FamilyINET))
+		assert.Error(t, (&schema.Dnat{TypeFlags: &schema.Flags{Flags: []string{schema.NATTypeFlagPrefix}}}).Validate(schema.FamilyIP))
+		assert.Error(t, schema.Nat{Masquerade: &schema.Masquerade{Enabled: true}}.Validate(schema.FamilyBridge))
+		assert.NoError(t, schema.Nat{Redirect: &schema.Redirect{Enabled: true}}.Validate(schema.FamilyINET))
+	})
+}
+
+func matchPayloadStatements() ([]schema.Statement, string) {
+	vlanID, port := float64(100), float64(22)
+	statements := []schema.Statement{
+		{Match: &schema.Match{
+			Op:    schema.OperEQ,
+			Left:  schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolVLAN, Field: schema.PayloadFieldVLANId}},
+			Right: schema.Expression{Float64: &vlanID},
+		}},
+		{Match: &schema.Match{
+			Op:    schema.OperEQ,
+			Left:  schema.Expression{Payload: schema.NewRawPayload(schema.PayloadBaseTH, 0, 16)},
+			Right: schema.Expression{Float64: &port},
+		}},
+	}
+
+	expectedVlanMatch := `"match":{"op":"==","left":{"payload":{"protocol":"vlan","field":"id"}},"right":100}`
+	expectedRawMatch := `"match":{"op":"==","left":{"payload":{"base":"th","offset":0,"len":16}},"right":22}`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s}]`, expectedVlanMatch, expectedRawMatch)
+
+	return statements, serializedStatements
+}
+
+func matchSetExpressionsStatements() ([]schema.Statement, string) {
+	_, ipNet, _ := net.ParseCIDR("10.0.0.0/8")
+	ipSAddr := schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolIP4, Field: schema.PayloadFieldIPSAddr}}
+	tcpDPort := schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolTCP, Field: schema.PayloadFieldTCPDPort}}
+	statements := []schema.Statement{
+		{Match: &schema.Match{Op: schema.OperEQ, Left: ipSAddr, Right: schema.NewPrefix(ipNet)}},
+		{Match: &schema.Match{Op: schema.OperEQ, Left: tcpDPort, Right: schema.NewPortRange(1000, 2000)}},
+		{Match: &schema.Match{
+			Op:   schema.OperEQ,
+			Left: schema.NewConcat(ipSAddr, tcpDPort),
+			Right: schema.NewSet(
+				schema.NewConcat(schema.NewString("10.0.0.1"), schema.NewNumber(22)),
+				schema.NewConcat(schema.NewString("10.0.0.2"), schema.NewNumber(80)),
+			),
+		}},
+	}
+
+	expectedPrefixMatch := `"match":{"op":"==","left":{"payload":{"protocol":"ip","field":"saddr"}},` +
+		`"right":{"prefix":{"addr":"10.0.0.0","len":8}}}`
+	expectedRangeMatch := `"match":{"op":"==","left":{"payload":{"protocol":"tcp","field":"dport"}},` +
+		`"right":{"range":[1000,2000]}}`
+	expectedConcatMatch := `"match":{"op":"==","left":{"concat":[` +
+		`{"payload":{"protocol":"ip","field":"saddr"}},{"payload":{"protocol":"tcp","field":"dport"}}]},` +
+		`"right":{"set":[{"concat":["10.0.0.1",22]},{"concat":["10.0.0.2",80]}]}}`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s},{%s}]`, expectedPrefixMatch, expectedRangeMatch, expectedConcatMatch)
+
+	return statements, serializedStatements
+}
+
+func matchBinaryOpStatements() ([]schema.Statement, string) {
+	tcpFlags := schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolTCP, Field: schema.PayloadFieldTCPFlags}}
+	synAck := schema.NewBinaryOp(schema.OperOR, schema.NewString("syn"), schema.NewString("ack"))
+	markHighByte := schema.NewBinaryOp(schema.OperAND,
+		schema.NewBinaryOp(schema.OperRSH, schema.Expression{Meta: &schema.Meta{Key: schema.MetaKeyMark}}, schema.NewNumber(8)),
+		schema.NewNumber(0xff),
+	)
+	statements := []schema.Statement{
+		{Match: &schema.Match{Op: schema.OperEQ, Left: schema.NewBinaryOp(schema.OperAND, tcpFlags, synAck), Right: schema.NewString("syn")}},
+		{Match: &schema.Match{Op: schema.OperEQ, Left: markHighByte, Right: schema.NewNumber(1)}},
+	}
+
+	expectedFlagsMatch := `"match":{"op":"==","left":{"\u0026":[{"payload":{"protocol":"tcp","field":"flags"}},` +
+		`{"|":["syn","ack"]}]},"right":"syn"}`
+	expectedMarkMatch := `"match":{"op":"==","left":{"\u0026":[{"\u003e\u003e":[{"meta":{"key":"mark"}},8]},255]},"right":1}`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s}]`, expectedFlagsMatch, expectedMarkMatch)
+
+	return statements, serializedStatements
+}
+
+func matchHeaderOptionStatements() ([]schema.Statement, string) {
+	statements := []schema.Statement{
+		{Match: &schema.Match{
+			Op:    schema.OperEQ,
+			Left:  schema.Expression{Exthdr: &schema.Exthdr{Name: schema.ExthdrFrag}},
+			Right: schema.NewBool(true),
+		}},
+		{Match: &schema.Match{
+			Op:    schema.OperEQ,
+			Left:  schema.Expression{Exthdr: &schema.Exthdr{Name: schema.ExthdrRouting, Field: schema.ExthdrFieldType}},
+			Right: schema.NewNumber(2),
+		}},
+		{Match: &schema.Match{
+			Op:    schema.OperLS,
+			Left:  schema.Expression{TcpOption: &schema.TcpOption{Name: schema.TcpOptionMaxSeg, Field: schema.TcpOptionFieldSize}},
+			Right: schema.NewNumber(1400),
+		}},
+		{Match: &schema.Match{
+			Op:    schema.OperEQ,
+			Left:  schema.Expression{SctpChunk: &schema.SctpChunk{Name: schema.SctpChunkInit}},
+			Right: schema.NewBool(false),
+		}},
+	}
+
+	expectedExthdrExists := `"match":{"op":"==","left":{"exthdr":{"name":"frag"}},"right":true}`
+	expectedExthdrField := `"match":{"op":"==","left":{"exthdr":{"name":"rt","field":"type"}},"right":2}`
+	expectedTcpOption := `"match":{"op":"\u003c","left":{"tcp option":{"name":"maxseg","field":"size"}},"right":1400}`
+	expectedSctpChunk := `"match":{"op":"==","left":{"sctp chunk":{"name":"init"}},"right":false}`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s},{%s},{%s}]`,
+		expectedExthdrExists, expectedExthdrField, expectedTcpOption, expectedSctpChunk)
+
+	return statements, serializedStatements
+}
+
+func loadBalancingStatements() ([]schema.Statement, string) {
+	ipSAddr := schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolIP4, Field: schema.PayloadFieldIPSAddr}}
+	seed := 7
+	dnat, _ := schema.NewWeightedDnat(schema.FamilyIP, schema.NumgenModeInc,
+		schema.DnatEndpoint{Addr: "10.0.0.1", Weight: 2},
+		schema.DnatEndpoint{Addr: "10.0.0.2", Weight: 1},
+	)
+	statements := []schema.Statement{
+		{Match: &schema.Match{
+			Op:    schema.OperEQ,
+			Left:  schema.Expression{Symhash: &schema.Symhash{Mod: 2}},
+			Right: schema.NewNumber(0),
+		}},
+		{Vmap: &schema.Vmap{
+			Key: schema.Expression{Jhash: &schema.Jhash{Mod: 2, Offset: 1, Expr: ipSAddr, Seed: &seed}},
+			Data: schema.NewSet(schema.NewMapPair(
+				schema.NewNumber(1), schema.Expression{RowData: json.RawMessage(`{"jump":{"target":"backend1"}}`)},
+			)),
+		}},
+		{Nat: schema.Nat{Dnat: dnat}},
+	}
+
+	expectedSymhashMatch := `"match":{"op":"==","left":{"symhash":{"mod":2}},"right":0}`
+	expectedJhashVmap := `"vmap":{"key":{"jhash":{"mod":2,"offset":1,"expr":{"payload":{"protocol":"ip","field":"saddr"}},` +
+		`"seed":7}},"data":{"set":[[1,{"jump":{"target":"backend1"}}]]}}`
+	expectedDnat := `"dnat":{"addr":{"map":{"key":{"numgen":{"mode":"inc","mod":3}},` +
+		`"data":{"set":[[{"range":[0,1]},"10.0.0.1"],[2,"10.0.0.2"]]}}}}`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s},{%s}]`, expectedSymhashMatch, expectedJhashVmap, expectedDnat)
+
+	return statements, serializedStatements
+}
+
+func mangleStatements() ([]schema.Statement, string) {
+	statements := []schema.Statement{
+		{Mangle: &schema.Mangle{
+			Key:   schema.Expression{Meta: &schema.Meta{Key: schema.MetaKeyMark}},
+			Value: schema.NewNumber(1),
+		}},
+		{Mangle: &schema.Mangle{
+			Key:   schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolIP4, Field: schema.PayloadFieldIPDscp}},
+			Value: schema.NewString("cs1"),
+		}},
+		{Mangle: &schema.Mangle{
+			Key:   schema.Expression{Ct: &schema.Ct{Key: schema.CtKeyMark}},
+			Value: schema.Expression{Meta: &schema.Meta{Key: schema.MetaKeyMark}},
+		}},
+	}
+
+	expectedMetaMark := `"mangle":{"key":{"meta":{"key":"mark"}},"value":1}`
+	expectedDscp := `"mangle":{"key":{"payload":{"protocol":"ip","field":"dscp"}},"value":"cs1"}`
+	expectedCtMark := `"mangle":{"key":{"ct":{"key":"mark"}},"value":{"meta":{"key":"mark"}}}`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s},{%s}]`, expectedMetaMark, expectedDscp, expectedCtMark)
+
+	return statements, serializedStatements
+}
+
+func packetForwardingStatements() ([]schema.Statement, string) {
+	tproxyAddr := schema.NewString("127.0.0.1")
+	tproxyPort := schema.NewNumber(9000)
+	queueNum := schema.NewRange(schema.NewNumber(3), schema.NewNumber(5))
+	dupDev := schema.NewString("eth1")
+	fwdAddr := schema.NewString("10.0.0.2")
+	statements := []schema.Statement{
+		{Tproxy: &schema.Tproxy{Family: schema.FamilyIP, Addr: &tproxyAddr, Port: &tproxyPort}},
+		{Queue: &schema.Queue{
+			Num:   &queueNum,
+			Flags: &schema.Flags{Flags: []string{schema.QueueFlagFanout, schema.QueueFlagBypass}},
+		}},
+		{Dup: &schema.Dup{Addr: schema.NewString("10.0.0.1"), Dev: &dupDev}},
+		{Fwd: &schema.Fwd{Dev: schema.NewString("eth2"), Family: schema.FamilyIP, Addr: &fwdAddr}},
+	}
+
+	expectedTproxy := `"tproxy":{"family":"ip","addr":"127.0.0.1","port":9000}`
+	expectedQueue := `"queue":{"num":{"range":[3,5]},"flags":["fanout","bypass"]}`
+	expectedDup := `"dup":{"addr":"10.0.0.1","dev":"eth1"}`
+	expectedFwd := `"fwd":{"dev":"eth2","family":"ip","addr":"10.0.0.2"}`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s},{%s},{%s}]`, expectedTproxy, expectedQueue, expectedDup, expectedFwd)
+
+	return statements, serializedStatements
+}
+
+func setStatements() ([]schema.Statement, string) {
+	ipSAddr := schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolIP4, Field: schema.PayloadFieldIPSAddr}}
+	statements := []schema.Statement{
+		{Set: &schema.SetStatement{
+			Op:   schema.SetOpAdd,
+			Elem: schema.Expression{Elem: &schema.Elem{Val: ipSAddr, Timeout: 10, Comment: "knock"}},
+			Set:  schema.NewSetReference("seen"),
+		}},
+		{Set: &schema.SetStatement{
+			Op:   schema.SetOpUpdate,
+			Elem: ipSAddr,
+			Set:  schema.NewSetReference("meter"),
+			Stmt: []schema.Statement{{Limit: &schema.Limit{Rate: 10, Per: schema.LimitPerSecond}}},
+		}},
+		{Set: &schema.SetStatement{
+			Op:   schema.SetOpDelete,
+			Elem: schema.Expression{Elem: &schema.Elem{Val: ipSAddr, Counter: &schema.Counter{}}},
+			Set:  schema.NewSetReference("seen"),
+		}},
+	}
+
+	expectedAdd := `"set":{"op":"add","elem":{"elem":{"val":{"payload":{"protocol":"ip","field":"saddr"}},` +
+		`"timeout":10,"comment":"knock"}},"set":"@seen"}`
+	expectedUpdate := `"set":{"op":"update","elem":{"payload":{"protocol":"ip","field":"saddr"}},"set":"@meter",` +
+		`"stmt":[{"limit":{"rate":10,"per":"second"}}]}`
+	expectedDelete := `"set":{"op":"delete","elem":{"elem":{"val":{"payload":{"protocol":"ip","field":"saddr"}},` +
+		`"counter":{"packets":0,"bytes":0}}},"set":"@seen"}`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s},{%s}]`, expectedAdd, expectedUpdate, expectedDelete)
+
+	return statements, serializedStatements
 }
 
 func testSerializationWith(t *testing.T, createStatements func() ([]schema.Statement, string)) {
@@ -494,6 +916,42 @@ func masqueradeStatements() ([]schema.Statement, string) {
 	return statements, serializedStatements
 }
 
+func natTypeFlagsStatements() ([]schema.Statement, string) {
+	familyIP4 := schema.FamilyIP
+	prefix := schema.Expression{Prefix: &schema.Prefix{Addr: "10.0.1.0", Len: 24}}
+	ports := schema.NewPortRange(8000, 8080)
+	netmap := schema.Statement{}
+	netmap.Snat = &schema.Snat{
+		Addr:      &prefix,
+		Family:    &familyIP4,
+		Port:      &ports,
+		Flags:     &schema.Flags{Flags: []string{schema.NATFlagNetmap}},
+		TypeFlags: &schema.Flags{Flags: []string{schema.NATTypeFlagPrefix}},
+	}
+
+	addressMap := schema.Expression{Map: &schema.MapExpr{
+		Key: schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolIP4, Field: schema.PayloadFieldIPSAddr}},
+		Data: schema.NewSet(schema.NewMapPair(
+			schema.NewString("10.0.0.1"), schema.NewConcat(schema.NewString("10.0.1.1"), schema.NewNumber(80)),
+		)),
+	}}
+	mapped := schema.Statement{}
+	mapped.Dnat = &schema.Dnat{
+		Addr:      &addressMap,
+		TypeFlags: &schema.Flags{Flags: []string{schema.NATTypeFlagConcat}},
+	}
+
+	statements := []schema.Statement{netmap, mapped}
+
+	expectedNetmap := `"snat":{"addr":{"prefix":{"addr":"10.0.1.0","len":24}},"family":"ip",` +
+		`"port":{"range":[8000,8080]},"flags":"netmap","type_flags":"prefix"}`
+	expectedMapped := `"dnat":{"addr":{"map":{"key":{"payload":{"protocol":"ip","field":"saddr"}},` +
+		`"data":{"set":[["10.0.0.1",{"concat":["10.0.1.1",80]}]]}}},"type_flags":"concat"}`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s}]`, expectedNetmap, expectedMapped)
+
+	return statements, serializedStatements
+}
+
 func redirectStatements() ([]schema.Statement, string) {
 	basic := schema.Statement{}
 	basic.Redirect = &schema.Redirect{Enabled: true}
@@ -0,0 +1,110 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config_test
+
+import (
+	"fmt"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestSecmark(t *testing.T) {
+	secmark := &schema.Secmark{
+		Family:  schema.FamilyINET,
+		Table:   tableName,
+		Name:    "sshtag",
+		Context: "system_u:object_r:ssh_server_packet_t:s0",
+	}
+	expectedSecmark := fmt.Sprintf(
+		`{"family":"inet","table":%q,"name":"sshtag","context":"system_u:object_r:ssh_server_packet_t:s0"}`, tableName,
+	)
+
+	t.Run("Add secmark object", func(t *testing.T) {
+		config := nft.NewConfig()
+		config.AddSecmark(secmark)
+
+		serializedConfig, err := config.ToJSON()
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf(`{"nftables":[{"secmark":%s}]}`, expectedSecmark), string(serializedConfig))
+
+		deserializedConfig := nft.NewConfig()
+		assert.NoError(t, deserializedConfig.FromJSON(serializedConfig))
+		assert.Equal(t, config, deserializedConfig)
+	})
+
+	t.Run("Delete secmark object", func(t *testing.T) {
+		config := nft.NewConfig()
+		config.DeleteSecmark(secmark)
+
+		serializedConfig, err := config.ToJSON()
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf(`{"nftables":[{"delete":{"secmark":%s}}]}`, expectedSecmark), string(serializedConfig))
+	})
+
+	t.Run("Lookup secmark object", func(t *testing.T) {
+		config := nft.NewConfig()
+		config.AddSecmark(secmark)
+
+		assert.Equal(t, secmark, config.LookupSecmark(&schema.Secmark{Family: schema.FamilyINET, Table: tableName, Name: "sshtag"}))
+		assert.Nil(t, config.LookupSecmark(&schema.Secmark{Family: schema.FamilyINET, Table: tableName, Name: "httptag"}))
+	})
+
+	t.Run("Add rule with secmark and notrack statements, check serialization", func(t *testing.T) {
+		testSerializationWith(t, secmarkStatements)
+	})
+	t.Run("Add rule with secmark and notrack statements, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, secmarkStatements)
+	})
+	t.Run("Add rule with secmark statement looked up in a map, check serialization", func(t *testing.T) {
+		testSerializationWith(t, secmarkMapStatements)
+	})
+	t.Run("Add rule with secmark statement looked up in a map, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, secmarkMapStatements)
+	})
+}
+
+func secmarkStatements() ([]schema.Statement, string) {
+	secmark := schema.NewString("sshtag")
+	statements := []schema.Statement{
+		{Notrack: true},
+		{Secmark: &secmark},
+	}
+
+	serializedStatements := `"expr":[{"notrack":null},{"secmark":"sshtag"}]`
+
+	return statements, serializedStatements
+}
+
+func secmarkMapStatements() ([]schema.Statement, string) {
+	secmapping := schema.Expression{Map: &schema.MapExpr{
+		Key:  schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolTCP, Field: schema.PayloadFieldTCPDPort}},
+		Data: schema.NewString(schema.NewSetReference("secmapping_in")),
+	}}
+	statements := []schema.Statement{{Secmark: &secmapping}}
+
+	serializedStatements := `"expr":[{"secmark":{"map":{` +
+		`"key":{"payload":{"protocol":"tcp","field":"dport"}},"data":"@secmapping_in"}}}]`
+
+	return statements, serializedStatements
+}
@@ -0,0 +1,52 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config
+
+import (
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// AddSynproxy appends the given synproxy object to the nftable config.
+// The object is added without an explicit action (`add`).
+func (c *Config) AddSynproxy(synproxy *schema.NamedSynproxy) {
+	nftable := schema.Nftable{Synproxy: synproxy}
+	c.Nftables = append(c.Nftables, nftable)
+}
+
+// DeleteSynproxy appends a given synproxy object to the nftable config
+// with the `delete` action.
+// Attempting to delete an object which rules reference, results with a failure when the config is applied.
+func (c *Config) DeleteSynproxy(synproxy *schema.NamedSynproxy) {
+	nftable := schema.Nftable{Delete: &schema.Objects{Synproxy: synproxy}}
+	c.Nftables = append(c.Nftables, nftable)
+}
+
+// LookupSynproxy searches the configuration for a synproxy object with the same table and name and returns it.
+// Mutating the returned object will result in mutating the configuration.
+func (c *Config) LookupSynproxy(toFind *schema.NamedSynproxy) *schema.NamedSynproxy {
+	for _, nftable := range c.Nftables {
+		if o := nftable.Synproxy; o != nil {
+			if o.Family == toFind.Family && o.Table == toFind.Table && o.Name == toFind.Name {
+				return o
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package config_test
+
+import (
+	"fmt"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestSynproxy(t *testing.T) {
+	synproxy := &schema.NamedSynproxy{
+		Family: schema.FamilyINET,
+		Table:  tableName,
+		Name:   "syn-flood",
+		Mss:    1460,
+		Wscale: 7,
+		Flags:  &schema.Flags{Flags: []string{schema.SynproxyFlagTimestamp, schema.SynproxyFlagSackPerm}},
+	}
+	expectedSynproxy := fmt.Sprintf(
+		`{"family":"inet","table":%q,"name":"syn-flood","mss":1460,"wscale":7,"flags":["timestamp","sack-perm"]}`, tableName,
+	)
+
+	t.Run("Add synproxy object", func(t *testing.T) {
+		config := nft.NewConfig()
+		config.AddSynproxy(synproxy)
+
+		serializedConfig, err := config.ToJSON()
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf(`{"nftables":[{"synproxy":%s}]}`, expectedSynproxy), string(serializedConfig))
+
+		deserializedConfig := nft.NewConfig()
+		assert.NoError(t, deserializedConfig.FromJSON(serializedConfig))
+		assert.Equal(t, config, deserializedConfig)
+	})
+
+	t.Run("Delete synproxy object", func(t *testing.T) {
+		config := nft.NewConfig()
+		config.DeleteSynproxy(synproxy)
+
+		serializedConfig, err := config.ToJSON()
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf(`{"nftables":[{"delete":{"synproxy":%s}}]}`, expectedSynproxy), string(serializedConfig))
+	})
+
+	t.Run("Lookup synproxy object", func(t *testing.T) {
+		config := nft.NewConfig()
+		config.AddSynproxy(synproxy)
+
+		assert.Equal(t, synproxy, config.LookupSynproxy(&schema.NamedSynproxy{
+			Family: schema.FamilyINET, Table: tableName, Name: "syn-flood",
+		}))
+		assert.Nil(t, config.LookupSynproxy(&schema.NamedSynproxy{Family: schema.FamilyIP, Table: tableName, Name: "syn-flood"}))
+	})
+
+	t.Run("Add rule with synproxy statements, check serialization", func(t *testing.T) {
+		testSerializationWith(t, synproxyStatements)
+	})
+	t.Run("Add rule with synproxy statements, check deserialization", func(t *testing.T) {
+		testDeserializationWith(t, synproxyStatements)
+	})
+}
+
+func synproxyStatements() ([]schema.Statement, string) {
+	mss, wscale := 1460, 7
+	statements := []schema.Statement{
+		{Synproxy: &schema.Synproxy{Enabled: true}},
+		{Synproxy: &schema.Synproxy{
+			Mss:    &mss,
+			Wscale: &wscale,
+			Flags:  &schema.Flags{Flags: []string{schema.SynproxyFlagTimestamp}},
+		}},
+		{Synproxy: &schema.Synproxy{Name: "syn-flood"}},
+	}
+
+	expectedNoValues := `"synproxy":null`
+	expectedWithValues := `"synproxy":{"mss":1460,"wscale":7,"flags":"timestamp"}`
+	expectedReference := `"synproxy":"syn-flood"`
+	serializedStatements := fmt.Sprintf(`"expr":[{%s},{%s},{%s}]`, expectedNoValues, expectedWithValues, expectedReference)
+
+	return statements, serializedStatements
+}
@@ -0,0 +1,255 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package nft
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// EnsureTable makes sure the table exists on the system, adding it only when missing.
+// It returns the configuration which was applied, empty when no change was needed.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func EnsureTable(ctx context.Context, table *schema.Table) (*Config, error) {
+	return defaultClient.EnsureTable(ctx, table)
+}
+
+// EnsureTable makes sure the table exists on the system, adding it only when missing.
+func (c *Client) EnsureTable(ctx context.Context, table *schema.Table) (*Config, error) {
+	live, err := c.readTableConfig(ctx, table.Family, table.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := NewConfig()
+	ensureTable(changes, live, table)
+	return c.applyChanges(ctx, changes)
+}
+
+// EnsureChain makes sure the chain and its table exist on the system, adding them only when missing.
+// A base chain with a different policy is updated, while a different type, hook or priority
+// results in an error, as these cannot be changed on an existing chain.
+// It returns the configuration which was applied, empty when no change was needed.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func EnsureChain(ctx context.Context, chain *schema.Chain) (*Config, error) {
+	return defaultClient.EnsureChain(ctx, chain)
+}
+
+// EnsureChain makes sure the chain and its table exist on the system, adding them only when missing.
+func (c *Client) EnsureChain(ctx context.Context, chain *schema.Chain) (*Config, error) {
+	live, err := c.readTableConfig(ctx, chain.Family, chain.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := NewConfig()
+	if err := ensureChain(changes, live, chain); err != nil {
+		return nil, err
+	}
+	return c.applyChanges(ctx, changes)
+}
+
+// EnsureRule makes sure the rule exists on the system, adding it only when missing.
+// The rule table and chain are expected to exist (see EnsureChain), an error is returned otherwise.
+//
+// A rule with a comment is identified by its comment: other rules in the chain with the same comment
+// but different statements are considered stale and are replaced by the given rule.
+// A rule without a comment is identified by its statements, adding it when no rule in the chain
+// has the same statements.
+// Statements are compared, ignoring counter values, with the ones listed by nft, which are normalized
+// (e.g. a single port set is listed as a port, a default operator is listed explicitly).
+// Statements which are not given in their normalized form never match a rule on the system, causing a rule
+// with a comment to be replaced and a rule without a comment to be added again, on each call.
+// Rules read from the system (e.g. by ReadChain) are in the normalized form.
+// It returns the configuration which was applied, empty when no change was needed.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func EnsureRule(ctx context.Context, rule *schema.Rule) (*Config, error) {
+	return defaultClient.EnsureRule(ctx, rule)
+}
+
+// EnsureRule makes sure the rule exists on the system, adding it only when missing.
+func (c *Client) EnsureRule(ctx context.Context, rule *schema.Rule) (*Config, error) {
+	live, err := c.readTableConfig(ctx, rule.Family, rule.Table)
+	if err != nil {
+		return nil, err
+	}
+	if live.LookupChain(&schema.Chain{Family: rule.Family, Table: rule.Table, Name: rule.Chain}) == nil {
+		return nil, fmt.Errorf("chain %s %s %s does not exist", rule.Family, rule.Table, rule.Chain)
+	}
+
+	changes := NewConfig()
+	var found bool
+	for _, liveRule := range chainRules(live, rule.Family, rule.Table, rule.Chain) {
+		switch {
+		case isSameRule(liveRule, rule):
+			found = true
+		case rule.Comment != "" && liveRule.Comment == rule.Comment && liveRule.Handle != nil:
+			changes.DeleteRule(ruleRef(liveRule))
+		}
+	}
+	if !found {
+		changes.AddRule(rule)
+	}
+
+	return c.applyChanges(ctx, changes)
+}
+
+// EnsureAbsent makes sure the given objects do not exist on the system, removing them only when present.
+// A rule is identified by its handle if set, otherwise by its statements and comment (ignoring counter values),
+// removing all matching rules.
+// A chain is flushed before it is removed.
+// It returns the configuration which was applied, empty when no change was needed.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func EnsureAbsent(ctx context.Context, objects *schema.Objects) (*Config, error) {
+	return defaultClient.EnsureAbsent(ctx, objects)
+}
+
+// EnsureAbsent makes sure the given objects do not exist on the system, removing them only when present.
+func (c *Client) EnsureAbsent(ctx context.Context, objects *schema.Objects) (*Config, error) {
+	changes := NewConfig()
+
+	if rule := objects.Rule; rule != nil {
+		live, err := c.readTableConfig(ctx, rule.Family, rule.Table)
+		if err != nil {
+			return nil, err
+		}
+		for _, liveRule := range chainRules(live, rule.Family, rule.Table, rule.Chain) {
+			matchHandle := rule.Handle != nil && liveRule.Handle != nil && *rule.Handle == *liveRule.Handle
+			if matchHandle || rule.Handle == nil && isSameRule(liveRule, rule) {
+				changes.DeleteRule(ruleRef(liveRule))
+			}
+		}
+	}
+
+	if chain := objects.Chain; chain != nil {
+		live, err := c.readTableConfig(ctx, chain.Family, chain.Table)
+		if err != nil {
+			return nil, err
+		}
+		ref := &schema.Chain{Family: chain.Family, Table: chain.Table, Name: chain.Name}
+		if live.LookupChain(ref) != nil {
+			changes.FlushChain(ref)
+			changes.DeleteChain(ref)
+		}
+	}
+
+	if table := objects.Table; table != nil {
+		live, err := c.readTableConfig(ctx, table.Family, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		if live.LookupTable(table) != nil {
+			changes.DeleteTable(table)
+		}
+	}
+
+	return c.applyChanges(ctx, changes)
+}
+
+// readTableConfig loads the configuration of a single table from the system.
+// An empty configuration is returned when the table does not exist.
+func (c *Client) readTableConfig(ctx context.Context, family, name string) (*Config, error) {
+	tables, err := c.ReadConfig(ctx, "tables")
+	if err != nil {
+		return nil, err
+	}
+	if tables.LookupTable(&schema.Table{Family: family, Name: name}) == nil {
+		return NewConfig(), nil
+	}
+	return c.ReadConfig(ctx, "table", family, name)
+}
+
+func ensureTable(changes, live *Config, table *schema.Table) {
+	if live.LookupTable(table) == nil {
+		changes.AddTable(table)
+	}
+}
+
+func ensureChain(changes, live *Config, chain *schema.Chain) error {
+	ensureTable(changes, live, &schema.Table{Family: chain.Family, Name: chain.Table})
+
+	liveChain := live.LookupChain(&schema.Chain{Family: chain.Family, Table: chain.Table, Name: chain.Name})
+	switch {
+	case liveChain == nil:
+		changes.AddChain(chain)
+	case chain.Hook == "":
+		// A regular chain, or a chain defined by name only, matches any existing chain.
+	case liveChain.Type != chain.Type || liveChain.Hook != chain.Hook || !isSamePriority(liveChain.Prio, chain.Prio):
+		return fmt.Errorf(
+			"chain %s %s %s exists with a different type, hook or priority", chain.Family, chain.Table, chain.Name,
+		)
+	case chain.Policy != "" && liveChain.Policy != chain.Policy:
+		changes.AddChain(chain)
+	}
+	return nil
+}
+
+func chainRules(c *Config, family, table, chain string) []*schema.Rule {
+	return c.LookupRule(&schema.Rule{Family: family, Table: table, Chain: chain})
+}
+
+// isSameRule reports whether the rules have the same statements and comment,
+// ignoring the counter values.
+func isSameRule(liveRule, rule *schema.Rule) bool {
+	c := NewConfig()
+	c.AddRule(withoutCounterValues(liveRule))
+	toFind := withoutCounterValues(rule)
+	toFind.Handle = nil
+	toFind.Index = nil
+	if toFind.Expr == nil {
+		toFind.Expr = []schema.Statement{}
+	}
+	return len(c.LookupRule(toFind)) == 1 && c.Nftables[0].Rule.Comment == rule.Comment
+}
+
+func withoutCounterValues(rule *schema.Rule) *schema.Rule {
+	r := *rule
+	r.Expr = make([]schema.Statement, len(rule.Expr))
+	for i, statement := range rule.Expr {
+		if statement.Counter != nil {
+			statement.Counter = &schema.Counter{Name: statement.Counter.Name, Ref: statement.Counter.Ref}
+		}
+		r.Expr[i] = statement
+	}
+	return &r
+}
+
+func ruleRef(rule *schema.Rule) *schema.Rule {
+	return &schema.Rule{Family: rule.Family, Table: rule.Table, Chain: rule.Chain, Handle: rule.Handle}
+}
+
+func isSamePriority(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (c *Client) applyChanges(ctx context.Context, changes *Config) (*Config, error) {
+	if len(changes.Nftables) == 0 {
+		return changes, nil
+	}
+	if err := c.ApplyConfig(ctx, changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,320 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+// Package eval provides an offline evaluation of packets against an nftables configuration.
+//
+// The evaluation walks the base chains registered on a hook by their priority,
+// evaluating the rules in the order they appear in the configuration.
+// It allows to assert the verdict given to a packet without applying the
+// configuration on the system (e.g. in unit tests).
+//
+//	packet := eval.NewPacket(schema.FamilyIP).
+//	    WithMeta(schema.MetaKeyIifName, "nic0").
+//	    WithPayload(schema.PayloadProtocolIP4, schema.PayloadFieldIPSAddr, "10.0.0.1")
+//	result, err := eval.Evaluate(config, schema.HookInput, packet)
+//
+// The evaluation supports payload (of known protocol header fields) and meta matches, verdicts (including jump,
+// goto, return and verdict maps) and chain policies.
+// Counters are ignored and NAT statements are treated as accepting the packet.
+// Any other expression or statement results in an UnsupportedError.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	nftconfig "github.com/networkplumbing/go-nft/nft/config"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// Result is the outcome of a packet evaluation.
+type Result struct {
+	// Verdict is the final verdict of the packet: schema.VerdictAccept or schema.VerdictDrop.
+	Verdict string
+	// Trace lists the rules which matched the packet, in the order they were evaluated.
+	Trace []TraceEntry
+}
+
+// TraceEntry describes a rule which matched the packet.
+type TraceEntry struct {
+	Chain  nftconfig.ChainRef
+	Handle *int
+	Rule   *schema.Rule
+}
+
+// UnsupportedError is returned when the configuration includes an expression or statement
+// which the evaluation does not support.
+type UnsupportedError struct {
+	What string
+	Data string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("unsupported %s in evaluation: %s", e.What, e.Data)
+}
+
+type outcome int
+
+const (
+	outcomeNone outcome = iota
+	outcomeAccept
+	outcomeDrop
+	outcomeReturn
+)
+
+type evaluator struct {
+	packet *Packet
+	chains map[nftconfig.ChainRef]*schema.Chain
+	rules  map[nftconfig.ChainRef][]*schema.Rule
+	trace  []TraceEntry
+}
+
+// Evaluate evaluates the packet against the base chains registered on the given hook,
+// in the tables matching the packet family (e.g. ip and inet tables for an IPv4 packet).
+// Base chains are evaluated by their priority, an accept verdict passes the packet
+// to the next base chain while a drop verdict is final.
+func Evaluate(c *nftconfig.Config, hook string, packet *Packet) (*Result, error) {
+	e := &evaluator{
+		packet: packet,
+		chains: map[nftconfig.ChainRef]*schema.Chain{},
+		rules:  map[nftconfig.ChainRef][]*schema.Rule{},
+	}
+
+	families := map[string]bool{}
+	for _, family := range tableFamilies(packet.Family) {
+		families[family] = true
+	}
+
+	var baseChains []*schema.Chain
+	for _, nftable := range c.Nftables {
+		if chain := nftable.Chain; chain != nil {
+			e.chains[chainRef(chain.Family, chain.Table, chain.Name)] = chain
+			if chain.Hook == hook && families[chain.Family] {
+				baseChains = append(baseChains, chain)
+			}
+		}
+		if rule := nftable.Rule; rule != nil {
+			ref := chainRef(rule.Family, rule.Table, rule.Chain)
+			e.rules[ref] = append(e.rules[ref], rule)
+		}
+	}
+	sort.SliceStable(baseChains, func(i, j int) bool {
+		return priority(baseChains[i]) < priority(baseChains[j])
+	})
+
+	for _, chain := range baseChains {
+		verdict, err := e.evalChain(chainRef(chain.Family, chain.Table, chain.Name), 0)
+		if err != nil {
+			return nil, err
+		}
+		if verdict == outcomeReturn && chain.Policy == schema.PolicyDrop {
+			verdict = outcomeDrop
+		}
+		if verdict == outcomeDrop {
+			return &Result{Verdict: schema.VerdictDrop, Trace: e.trace}, nil
+		}
+	}
+	return &Result{Verdict: schema.VerdictAccept, Trace: e.trace}, nil
+}
+
+// evalChain evaluates the rules of the chain, returning accept or drop when a final verdict
+// is reached, otherwise return.
+func (e *evaluator) evalChain(ref nftconfig.ChainRef, depth int) (outcome, error) {
+	if depth >= nftconfig.MaxJumpDepth {
+		return outcomeNone, fmt.Errorf("jump depth limit reached at chain %s", ref)
+	}
+	if _, exists := e.chains[ref]; !exists {
+		return outcomeNone, fmt.Errorf("chain %s is not defined", ref)
+	}
+
+	for _, rule := range e.rules[ref] {
+		result, err := e.evalRule(ref, rule, depth)
+		if err != nil {
+			return outcomeNone, err
+		}
+		switch result {
+		case outcomeAccept, outcomeDrop, outcomeReturn:
+			return result, nil
+		}
+	}
+	return outcomeReturn, nil
+}
+
+// evalRule evaluates the rule statements, returning none when the evaluation
+// should proceed to the next rule.
+func (e *evaluator) evalRule(ref nftconfig.ChainRef, rule *schema.Rule, depth int) (outcome, error) {
+	traced := false
+	trace := func() {
+		if !traced {
+			e.trace = append(e.trace, TraceEntry{Chain: ref, Handle: rule.Handle, Rule: rule})
+			traced = true
+		}
+	}
+
+	for _, statement := range rule.Expr {
+		switch {
+		case statement.Match != nil:
+			matched, err := e.evalMatch(statement.Match)
+			if err != nil || !matched {
+				return outcomeNone, err
+			}
+		case statement.Counter != nil:
+		case statement.Vmap != nil:
+			verdict, matched, err := e.evalVerdictMap(statement.Vmap)
+			if err != nil || !matched {
+				return outcomeNone, err
+			}
+			trace()
+			return e.evalVerdict(ref, verdict, depth)
+		case statement.Snat != nil, statement.Dnat != nil, statement.Masquerade != nil, statement.Redirect != nil:
+			trace()
+			return outcomeAccept, nil
+		case isVerdict(statement.Verdict):
+			trace()
+			return e.evalVerdict(ref, statement.Verdict, depth)
+		default:
+			data, _ := json.Marshal(statement)
+			return outcomeNone, &UnsupportedError{What: "statement", Data: string(data)}
+		}
+	}
+	trace()
+	return outcomeNone, nil
+}
+
+func (e *evaluator) evalVerdict(ref nftconfig.ChainRef, verdict schema.Verdict, depth int) (outcome, error) {
+	switch {
+	case verdict.Accept:
+		return outcomeAccept, nil
+	case verdict.Drop:
+		return outcomeDrop, nil
+	case verdict.Return:
+		return outcomeReturn, nil
+	case verdict.Continue:
+		return outcomeNone, nil
+	case verdict.Jump != nil:
+		result, err := e.evalChain(chainRef(ref.Family, ref.Table, verdict.Jump.Target), depth+1)
+		if result == outcomeReturn {
+			result = outcomeNone
+		}
+		return result, err
+	case verdict.Goto != nil:
+		return e.evalChain(chainRef(ref.Family, ref.Table, verdict.Goto.Target), depth+1)
+	}
+	return outcomeNone, nil
+}
+
+func (e *evaluator) evalMatch(match *schema.Match) (bool, error) {
+	value, exists, err := e.packet.value(match.Left)
+	if err != nil || !exists {
+		return false, err
+	}
+	right, err := decodeExpression(match.Right)
+	if err != nil {
+		return false, err
+	}
+	return matchValue(match.Op, value, right)
+}
+
+// evalVerdictMap looks up the key value in the verdict map elements, returning the mapped verdict.
+func (e *evaluator) evalVerdictMap(vmap *schema.Vmap) (schema.Verdict, bool, error) {
+	value, exists, err := e.packet.value(vmap.Key)
+	if err != nil || !exists {
+		return schema.Verdict{}, false, err
+	}
+	data, err := decodeExpression(vmap.Data)
+	if err != nil {
+		return schema.Verdict{}, false, err
+	}
+	dataMap, _ := data.(map[string]interface{})
+	elements, isSet := dataMap["set"].([]interface{})
+	if !isSet {
+		return schema.Verdict{}, false, &UnsupportedError{What: "verdict map", Data: fmt.Sprintf("%v", data)}
+	}
+
+	for _, element := range elements {
+		pair, isPair := element.([]interface{})
+		if !isPair || len(pair) != 2 {
+			return schema.Verdict{}, false, &UnsupportedError{What: "verdict map element", Data: fmt.Sprintf("%v", element)}
+		}
+		matched, err := matchValue(schema.OperEQ, value, pair[0])
+		if err != nil {
+			return schema.Verdict{}, false, err
+		}
+		if matched {
+			verdict, err := decodeVerdict(pair[1])
+			return verdict, err == nil, err
+		}
+	}
+	return schema.Verdict{}, false, nil
+}
+
+func decodeVerdict(data interface{}) (schema.Verdict, error) {
+	var statement schema.Statement
+	if name, isString := data.(string); isString {
+		data = map[string]interface{}{name: nil}
+	}
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return schema.Verdict{}, err
+	}
+	if err := json.Unmarshal(rawData, &statement); err != nil {
+		return schema.Verdict{}, err
+	}
+	if !isVerdict(statement.Verdict) {
+		return schema.Verdict{}, &UnsupportedError{What: "verdict", Data: string(rawData)}
+	}
+	return statement.Verdict, nil
+}
+
+func isVerdict(v schema.Verdict) bool {
+	return v.Accept || v.Drop || v.Return || v.Continue || v.Jump != nil || v.Goto != nil
+}
+
+func decodeExpression(e schema.Expression) (interface{}, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func tableFamilies(packetFamily string) []string {
+	switch packetFamily {
+	case schema.FamilyIP, schema.FamilyIP6:
+		return []string{packetFamily, schema.FamilyINET}
+	default:
+		return []string{packetFamily}
+	}
+}
+
+func priority(chain *schema.Chain) int {
+	if chain.Prio == nil {
+		return 0
+	}
+	return *chain.Prio
+}
+
+func chainRef(family, table, name string) nftconfig.ChainRef {
+	return nftconfig.ChainRef{Family: family, Table: table, Name: name}
+}
@@ -0,0 +1,260 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package eval_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/eval"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestEvaluate(t *testing.T) {
+	config := buildSSHFilterConfig()
+
+	tests := []struct {
+		name            string
+		packet          *eval.Packet
+		expectedVerdict string
+		expectedTrace   []int
+	}{
+		{
+			name:            "TCP SYN to port 22 from an allowed network on nic0 is accepted",
+			packet:          tcpPacket("nic0", "10.0.0.1", "22"),
+			expectedVerdict: schema.VerdictAccept,
+			expectedTrace:   []int{1, 10},
+		},
+		{
+			name:            "TCP SYN to port 22 from a denied address on nic0 is dropped",
+			packet:          tcpPacket("nic0", "10.0.0.66", "22"),
+			expectedVerdict: schema.VerdictDrop,
+			expectedTrace:   []int{1, 11},
+		},
+		{
+			name:            "TCP SYN to port 22 on another interface falls to the policy",
+			packet:          tcpPacket("nic1", "10.0.0.1", "22"),
+			expectedVerdict: schema.VerdictDrop,
+			expectedTrace:   nil,
+		},
+		{
+			name:            "TCP SYN to a web port returns to the base chain and falls to the policy",
+			packet:          tcpPacket("nic0", "10.0.0.1", "443"),
+			expectedVerdict: schema.VerdictDrop,
+			expectedTrace:   []int{1, 12},
+		},
+		{
+			name:            "Loopback traffic is accepted",
+			packet:          tcpPacket("lo", "127.0.0.1", "443"),
+			expectedVerdict: schema.VerdictAccept,
+			expectedTrace:   []int{2},
+		},
+		{
+			name:            "UDP to port 53 is accepted through the verdict map",
+			packet:          udpPacket("nic0", "192.168.0.1", "53"),
+			expectedVerdict: schema.VerdictAccept,
+			expectedTrace:   []int{1, 13, 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := eval.Evaluate(config, schema.HookInput, tt.packet)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedVerdict, result.Verdict)
+
+			var trace []int
+			for _, entry := range result.Trace {
+				trace = append(trace, *entry.Handle)
+			}
+			assert.Equal(t, tt.expectedTrace, trace)
+		})
+	}
+
+	t.Run("IPv6 packet is not evaluated by an IPv4 table", func(t *testing.T) {
+		packet := eval.NewPacket(schema.FamilyIP6).WithMeta(schema.MetaKeyIifName, "nic0")
+		result, err := eval.Evaluate(config, schema.HookInput, packet)
+		assert.NoError(t, err)
+		assert.Equal(t, schema.VerdictAccept, result.Verdict)
+		assert.Empty(t, result.Trace)
+	})
+
+	t.Run("Unsupported expressions are reported", func(t *testing.T) {
+		c := nft.NewConfig()
+		table := nft.NewTable("filter", nft.FamilyIP)
+		chain := newBaseChain(c, table, "input", nft.PolicyAccept)
+		matchCT := []schema.Statement{{Match: &schema.Match{
+			Op:    schema.OperEQ,
+			Left:  schema.Expression{RowData: json.RawMessage(`{"ct":{"key":"state"}}`)},
+			Right: schema.Expression{String: stringPtr("established")},
+		}}}
+		c.AddRule(nft.NewRule(table, chain, matchCT, nil, nil, ""))
+
+		_, err := eval.Evaluate(c, schema.HookInput, tcpPacket("nic0", "10.0.0.1", "22"))
+		var unsupportedErr *eval.UnsupportedError
+		assert.ErrorAs(t, err, &unsupportedErr)
+	})
+
+	t.Run("Raw and unknown payload expressions are reported", func(t *testing.T) {
+		for _, payload := range []*schema.Payload{
+			schema.NewRawPayload(schema.PayloadBaseTH, 16, 16),
+			{Protocol: schema.PayloadProtocolTCP, Field: "unknown"},
+		} {
+			c := nft.NewConfig()
+			table := nft.NewTable("filter", nft.FamilyIP)
+			chain := newBaseChain(c, table, "input", nft.PolicyAccept)
+			matchPayload := []schema.Statement{{Match: &schema.Match{
+				Op:    schema.OperEQ,
+				Left:  schema.Expression{Payload: payload},
+				Right: schema.NewNumber(22),
+			}}}
+			c.AddRule(nft.NewRule(table, chain, matchPayload, nil, nil, ""))
+
+			_, err := eval.Evaluate(c, schema.HookInput, tcpPacket("nic0", "10.0.0.1", "22"))
+			var unsupportedErr *eval.UnsupportedError
+			assert.ErrorAs(t, err, &unsupportedErr)
+		}
+	})
+}
+
+// buildSSHFilterConfig builds the following configuration (rule handles in brackets):
+//
+//	table ip filter {
+//	  chain input { type filter hook input priority 0; policy drop;
+//	    [1] iifname "nic0*" jump ingress
+//	    [2] iifname "lo" accept
+//	  }
+//	  chain ingress {
+//	    [10] ip saddr 10.0.0.0/24 ip saddr != 10.0.0.66 tcp dport 22 accept
+//	    [11] tcp dport 22 drop
+//	    [12] tcp dport { 80, 443 } ip saddr 10.0.0.0-10.0.0.100 counter return
+//	    [13] goto dns
+//	  }
+//	  chain dns {
+//	    [20] udp dport vmap { 53 : accept }
+//	  }
+//	}
+func buildSSHFilterConfig() *nft.Config {
+	c := nft.NewConfig()
+	table := nft.NewTable("filter", nft.FamilyIP)
+	c.AddTable(table)
+
+	input := newBaseChain(c, table, "input", nft.PolicyDrop)
+	ingress := nft.NewRegularChain(table, "ingress")
+	c.AddChain(ingress)
+	dns := nft.NewRegularChain(table, "dns")
+	c.AddChain(dns)
+
+	addRule(c, table, input, 1,
+		match(schema.OperEQ, metaExpression(schema.MetaKeyIifName), schema.Expression{String: stringPtr("nic0*")}),
+		schema.Statement{Verdict: schema.Verdict{Jump: &schema.ToTarget{Target: ingress.Name}}},
+	)
+	addRule(c, table, input, 2,
+		match(schema.OperEQ, metaExpression(schema.MetaKeyIifName), schema.Expression{String: stringPtr("lo")}),
+		schema.Statement{Verdict: schema.Accept()},
+	)
+
+	addRule(c, table, ingress, 10,
+		match(schema.OperEQ, ipSAddr(), rowData(`{"prefix":{"addr":"10.0.0.0","len":24}}`)),
+		match(schema.OperNEQ, ipSAddr(), schema.Expression{String: stringPtr("10.0.0.66")}),
+		match(schema.OperEQ, tcpDPort(), schema.Expression{Float64: float64Ptr(22)}),
+		schema.Statement{Verdict: schema.Accept()},
+	)
+	addRule(c, table, ingress, 11,
+		match(schema.OperEQ, tcpDPort(), schema.Expression{Float64: float64Ptr(22)}),
+		schema.Statement{Verdict: schema.Drop()},
+	)
+	addRule(c, table, ingress, 12,
+		match(schema.OperEQ, tcpDPort(), rowData(`{"set":[80,443]}`)),
+		match(schema.OperEQ, ipSAddr(), rowData(`{"range":["10.0.0.0","10.0.0.100"]}`)),
+		schema.Statement{Counter: &schema.Counter{}},
+		schema.Statement{Verdict: schema.Return()},
+	)
+	addRule(c, table, ingress, 13, schema.Statement{Verdict: schema.Verdict{Goto: &schema.ToTarget{Target: dns.Name}}})
+
+	addRule(c, table, dns, 20, schema.Statement{Vmap: &schema.Vmap{
+		Key:  schema.Expression{Payload: &schema.Payload{Protocol: "udp", Field: "dport"}},
+		Data: rowData(`{"set":[[53,{"accept":null}]]}`),
+	}})
+
+	return c
+}
+
+func newBaseChain(c *nft.Config, table *schema.Table, name string, policy nft.ChainPolicy) *schema.Chain {
+	ctype, hook, prio := nft.TypeFilter, nft.HookInput, 0
+	chain := nft.NewChain(table, name, &ctype, &hook, &prio, &policy)
+	c.AddChain(chain)
+	return chain
+}
+
+func addRule(c *nft.Config, table *schema.Table, chain *schema.Chain, handle int, statements ...schema.Statement) {
+	c.AddRule(nft.NewRule(table, chain, statements, &handle, nil, ""))
+}
+
+func match(op string, left, right schema.Expression) schema.Statement {
+	return schema.Statement{Match: &schema.Match{Op: op, Left: left, Right: right}}
+}
+
+func metaExpression(key string) schema.Expression {
+	return schema.Expression{Meta: &schema.Meta{Key: key}}
+}
+
+func ipSAddr() schema.Expression {
+	return schema.Expression{Payload: &schema.Payload{
+		Protocol: schema.PayloadProtocolIP4,
+		Field:    schema.PayloadFieldIPSAddr,
+	}}
+}
+
+func tcpDPort() schema.Expression {
+	return schema.Expression{Payload: &schema.Payload{Protocol: "tcp", Field: "dport"}}
+}
+
+func rowData(data string) schema.Expression {
+	return schema.Expression{RowData: json.RawMessage(data)}
+}
+
+func tcpPacket(iifname, saddr, dport string) *eval.Packet {
+	return eval.NewPacket(schema.FamilyIP).
+		WithMeta(schema.MetaKeyIifName, iifname).
+		WithMeta(schema.MetaKeyL4Proto, "tcp").
+		WithPayload(schema.PayloadProtocolIP4, schema.PayloadFieldIPSAddr, saddr).
+		WithPayload("tcp", "dport", dport).
+		WithPayload("tcp", "flags", "syn")
+}
+
+func udpPacket(iifname, saddr, dport string) *eval.Packet {
+	return eval.NewPacket(schema.FamilyIP).
+		WithMeta(schema.MetaKeyIifName, iifname).
+		WithMeta(schema.MetaKeyL4Proto, "udp").
+		WithPayload(schema.PayloadProtocolIP4, schema.PayloadFieldIPSAddr, saddr).
+		WithPayload("udp", "dport", dport)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
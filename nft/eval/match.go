@@ -0,0 +1,166 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// matchValue matches the packet value against the decoded JSON of the right side expression.
+// The right side may be a scalar (string or number), a prefix, a range or a set of these.
+func matchValue(op string, value string, right interface{}) (bool, error) {
+	switch op {
+	case schema.OperEQ, schema.OperIN:
+		return isMember(value, right)
+	case schema.OperNEQ:
+		isMember, err := isMember(value, right)
+		return !isMember, err
+	case schema.OperLS, schema.OperGR, schema.OperLSE, schema.OperGRE:
+		result, err := compareScalar(value, right)
+		if err != nil {
+			return false, err
+		}
+		switch op {
+		case schema.OperLS:
+			return result < 0, nil
+		case schema.OperGR:
+			return result > 0, nil
+		case schema.OperLSE:
+			return result <= 0, nil
+		default:
+			return result >= 0, nil
+		}
+	}
+	return false, &UnsupportedError{What: "match operator", Data: op}
+}
+
+func isMember(value string, right interface{}) (bool, error) {
+	switch r := right.(type) {
+	case string:
+		if strings.HasSuffix(r, "*") {
+			return strings.HasPrefix(value, strings.TrimSuffix(r, "*")), nil
+		}
+		result, err := compareScalar(value, r)
+		return err == nil && result == 0, nil
+	case float64:
+		result, err := compareScalar(value, r)
+		return err == nil && result == 0, nil
+	case []interface{}:
+		return isSetMember(value, r)
+	case map[string]interface{}:
+		if set, isSet := r["set"]; isSet {
+			elements, isList := set.([]interface{})
+			if !isList {
+				elements = []interface{}{set}
+			}
+			return isSetMember(value, elements)
+		}
+		if prefix, isPrefix := r["prefix"].(map[string]interface{}); isPrefix {
+			return isPrefixMember(value, prefix)
+		}
+		if bounds, isRange := r["range"].([]interface{}); isRange && len(bounds) == 2 {
+			lower, err := compareScalar(value, bounds[0])
+			if err != nil {
+				return false, err
+			}
+			upper, err := compareScalar(value, bounds[1])
+			if err != nil {
+				return false, err
+			}
+			return lower >= 0 && upper <= 0, nil
+		}
+	}
+	return false, &UnsupportedError{What: "expression", Data: fmt.Sprintf("%v", right)}
+}
+
+func isSetMember(value string, elements []interface{}) (bool, error) {
+	for _, element := range elements {
+		matched, err := isMember(value, element)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+func isPrefixMember(value string, prefix map[string]interface{}) (bool, error) {
+	addr, _ := prefix["addr"].(string)
+	length, _ := prefix["len"].(float64)
+	_, ipNet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", addr, int(length)))
+	if err != nil {
+		return false, &UnsupportedError{What: "prefix", Data: fmt.Sprintf("%v", prefix)}
+	}
+	ip := net.ParseIP(value)
+	return ip != nil && ipNet.Contains(ip), nil
+}
+
+// compareScalar compares the packet value with a scalar, as IP addresses, numbers or strings,
+// returning -1, 0 or +1 when the value is less than, equal or greater than the scalar.
+func compareScalar(value string, scalar interface{}) (int, error) {
+	switch s := scalar.(type) {
+	case float64:
+		number, err := parseNumber(value)
+		if err != nil {
+			return 0, err
+		}
+		return compareNumbers(number, s), nil
+	case string:
+		if valueIP, scalarIP := net.ParseIP(value), net.ParseIP(s); valueIP != nil && scalarIP != nil {
+			return compareIPs(valueIP, scalarIP), nil
+		}
+		valueNumber, valueErr := parseNumber(value)
+		scalarNumber, scalarErr := parseNumber(s)
+		if valueErr == nil && scalarErr == nil {
+			return compareNumbers(valueNumber, scalarNumber), nil
+		}
+		return strings.Compare(value, s), nil
+	}
+	return 0, &UnsupportedError{What: "expression", Data: fmt.Sprintf("%v", scalar)}
+}
+
+func parseNumber(value string) (float64, error) {
+	if number, err := strconv.ParseUint(value, 0, 64); err == nil {
+		return float64(number), nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareIPs(a, b net.IP) int {
+	if a4, b4 := a.To4(), b.To4(); a4 != nil && b4 != nil {
+		return bytes.Compare(a4, b4)
+	}
+	return bytes.Compare(a.To16(), b.To16())
+}
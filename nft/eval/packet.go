@@ -0,0 +1,77 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package eval
+
+import "github.com/networkplumbing/go-nft/nft/schema"
+
+// Packet describes the packet to evaluate.
+// Only the fields which are set are considered present in the packet, a match
+// on a missing field (e.g. a TCP port on a UDP packet) does not match.
+// Values are kept in their nftables textual form (e.g. "10.0.0.1", "22", "nic0").
+type Packet struct {
+	// Family is the packet family: ip, ip6, arp or bridge/netdev for L2 hooks.
+	Family  string
+	meta    map[string]string
+	payload map[payloadKey]string
+}
+
+type payloadKey struct {
+	protocol string
+	field    string
+}
+
+// NewPacket returns a new packet of the given family (e.g. schema.FamilyIP).
+func NewPacket(family string) *Packet {
+	return &Packet{
+		Family:  family,
+		meta:    map[string]string{},
+		payload: map[payloadKey]string{},
+	}
+}
+
+// WithMeta sets the value of a meta key (e.g. schema.MetaKeyIifName).
+func (p *Packet) WithMeta(key, value string) *Packet {
+	p.meta[key] = value
+	return p
+}
+
+// WithPayload sets the value of a payload header field (e.g. schema.PayloadProtocolIP4, schema.PayloadFieldIPSAddr).
+func (p *Packet) WithPayload(protocol, field, value string) *Packet {
+	p.payload[payloadKey{protocol: protocol, field: field}] = value
+	return p
+}
+
+func (p *Packet) value(e schema.Expression) (string, bool, error) {
+	switch {
+	case e.Payload != nil && (e.Payload.IsRaw() || e.Payload.Validate() != nil):
+		// Raw payloads and unknown header fields cannot be resolved from the packet fields.
+		data, _ := e.MarshalJSON()
+		return "", false, &UnsupportedError{What: "payload expression", Data: string(data)}
+	case e.Payload != nil:
+		value, exists := p.payload[payloadKey{protocol: e.Payload.Protocol, field: e.Payload.Field}]
+		return value, exists, nil
+	case e.Meta != nil:
+		value, exists := p.meta[e.Meta.Key]
+		return value, exists, nil
+	default:
+		data, _ := e.MarshalJSON()
+		return "", false, &UnsupportedError{What: "expression", Data: string(data)}
+	}
+}
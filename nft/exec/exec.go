@@ -31,24 +31,91 @@ import (
 
 const (
 	cmdBin     = "nft"
+	cmdCheck   = "-c"
 	cmdHandle  = "-a"
 	cmdEcho    = "-e"
 	cmdFile    = "-f"
 	cmdJSON    = "-j"
 	cmdList    = "list"
+	cmdReset   = "reset"
 	cmdRuleset = "ruleset"
 	cmdStdin   = "-"
 )
 
+// CommandFunc creates the command which runs the nft executable with the given arguments.
+// It allows to wrap the execution, e.g. to run nft in another network namespace with nsenter:
+//
+//	func(ctx context.Context, name string, args ...string) *exec.Cmd {
+//		return exec.CommandContext(ctx, "nsenter", append([]string{"--net=/run/netns/ns1", name}, args...)...)
+//	}
+type CommandFunc func(ctx context.Context, name string, args ...string) *exec.Cmd
+
+// Executor runs the nft executable.
+// The zero value runs the `nft` executable found in PATH, with the environment of the current process.
+type Executor struct {
+	// Path is the nft executable path, it defaults to `nft` resolved from PATH.
+	Path string
+	// Env is the environment of the executable, in the `key=value` form.
+	// When nil, the environment of the current process is used.
+	Env []string
+	// GlobalArgs are passed to the executable before the command arguments,
+	// e.g. `--includepath /etc/nftables` or `--optimize`.
+	GlobalArgs []string
+	// Command creates the command to run, it defaults to exec.CommandContext.
+	Command CommandFunc
+}
+
+var defaultExecutor = &Executor{}
+
 // ReadConfig loads the nftables configuration from the system and
 // returns it as a nftables config structure.
 // The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
 func ReadConfig(ctx context.Context, filterCommands ...string) (*nftconfig.Config, error) {
+	return defaultExecutor.ReadConfig(ctx, filterCommands...)
+}
+
+// Reset resets the stateful objects selected by the reset command (e.g. `counters table ip mytable`)
+// and returns them, with their values before the reset, as a nftables config structure.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func Reset(ctx context.Context, resetCommand ...string) (*nftconfig.Config, error) {
+	return defaultExecutor.Reset(ctx, resetCommand...)
+}
+
+// ApplyConfig applies the given nftables config on the system.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ApplyConfig(ctx context.Context, c *nftconfig.Config) error {
+	return defaultExecutor.ApplyConfig(ctx, c)
+}
+
+// CheckConfig verifies the given nftables config can be applied on the system,
+// without actually applying it.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func CheckConfig(ctx context.Context, c *nftconfig.Config) error {
+	return defaultExecutor.CheckConfig(ctx, c)
+}
+
+// CheckScript verifies the given nftables script, in the native nft syntax, can be applied
+// on the system, without actually applying it.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func CheckScript(ctx context.Context, script string) error {
+	return defaultExecutor.CheckScript(ctx, script)
+}
+
+// ApplyConfigEcho applies the given nftables config on the system, echoing
+// back the added elements with their assigned handles
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ApplyConfigEcho(ctx context.Context, c *nftconfig.Config) (*nftconfig.Config, error) {
+	return defaultExecutor.ApplyConfigEcho(ctx, c)
+}
+
+// ReadConfig loads the nftables configuration from the system and
+// returns it as a nftables config structure.
+func (e *Executor) ReadConfig(ctx context.Context, filterCommands ...string) (*nftconfig.Config, error) {
 	whatToList := cmdRuleset
 	if len(filterCommands) > 0 {
 		whatToList = strings.Join(filterCommands, " ")
 	}
-	stdout, err := execCommand(ctx, nil, cmdJSON, cmdList, whatToList)
+	stdout, err := e.execCommand(ctx, nil, cmdJSON, cmdList, whatToList)
 	if err != nil {
 		return nil, err
 	}
@@ -61,15 +128,55 @@ func ReadConfig(ctx context.Context, filterCommands ...string) (*nftconfig.Confi
 	return config, nil
 }
 
+// Reset resets the stateful objects selected by the reset command (e.g. `counters table ip mytable`)
+// and returns them, with their values before the reset, as a nftables config structure.
+func (e *Executor) Reset(ctx context.Context, resetCommand ...string) (*nftconfig.Config, error) {
+	stdout, err := e.execCommand(ctx, nil, cmdJSON, cmdReset, strings.Join(resetCommand, " "))
+	if err != nil {
+		return nil, err
+	}
+
+	config := nftconfig.New()
+	if err := config.FromJSON(stdout.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to parse reset: %v", err)
+	}
+
+	return config, nil
+}
+
 // ApplyConfig applies the given nftables config on the system.
-// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
-func ApplyConfig(ctx context.Context, c *nftconfig.Config) error {
+func (e *Executor) ApplyConfig(ctx context.Context, c *nftconfig.Config) error {
 	data, err := c.ToJSON()
 	if err != nil {
 		return err
 	}
 
-	if _, err := execCommand(ctx, data, cmdJSON, cmdFile, cmdStdin); err != nil {
+	if _, err := e.execCommand(ctx, data, cmdJSON, cmdFile, cmdStdin); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckConfig verifies the given nftables config can be applied on the system,
+// without actually applying it.
+func (e *Executor) CheckConfig(ctx context.Context, c *nftconfig.Config) error {
+	data, err := c.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	if _, err := e.execCommand(ctx, data, cmdCheck, cmdJSON, cmdFile, cmdStdin); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckScript verifies the given nftables script, in the native nft syntax, can be applied
+// on the system, without actually applying it.
+func (e *Executor) CheckScript(ctx context.Context, script string) error {
+	if _, err := e.execCommand(ctx, []byte(script), cmdCheck, cmdFile, cmdStdin); err != nil {
 		return err
 	}
 
@@ -78,14 +185,13 @@ func ApplyConfig(ctx context.Context, c *nftconfig.Config) error {
 
 // ApplyConfigEcho applies the given nftables config on the system, echoing
 // back the added elements with their assigned handles
-// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
-func ApplyConfigEcho(ctx context.Context, c *nftconfig.Config) (*nftconfig.Config, error) {
+func (e *Executor) ApplyConfigEcho(ctx context.Context, c *nftconfig.Config) (*nftconfig.Config, error) {
 	data, err := c.ToJSON()
 	if err != nil {
 		return nil, err
 	}
 
-	stdout, err := execCommand(ctx, data, cmdHandle, cmdEcho, cmdJSON, cmdFile, cmdStdin)
+	stdout, err := e.execCommand(ctx, data, cmdHandle, cmdEcho, cmdJSON, cmdFile, cmdStdin)
 	if err != nil {
 		return nil, err
 	}
@@ -98,8 +204,20 @@ func ApplyConfigEcho(ctx context.Context, c *nftconfig.Config) (*nftconfig.Confi
 	return config, nil
 }
 
-func execCommand(ctx context.Context, input []byte, args ...string) (*bytes.Buffer, error) {
-	cmd := exec.CommandContext(ctx, cmdBin, args...)
+func (e *Executor) execCommand(ctx context.Context, input []byte, args ...string) (*bytes.Buffer, error) {
+	path := e.Path
+	if path == "" {
+		path = cmdBin
+	}
+	command := e.Command
+	if command == nil {
+		command = exec.CommandContext
+	}
+
+	cmd := command(ctx, path, append(append([]string{}, e.GlobalArgs...), args...)...)
+	if e.Env != nil {
+		cmd.Env = e.Env
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stderr = &stderr
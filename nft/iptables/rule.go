@@ -0,0 +1,531 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package iptables
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// Supported match modules (`-m`).
+// Modules which only enable options (e.g. tcp) are translated through their options.
+var supportedModules = map[string]bool{
+	"tcp":       true,
+	"udp":       true,
+	"sctp":      true,
+	"multiport": true,
+	"comment":   true,
+	"conntrack": true,
+	"state":     true,
+	"mark":      true,
+	"icmp":      true,
+	"icmp6":     true,
+}
+
+// ruleBuilder accumulates the statements of a single rule.
+type ruleBuilder struct {
+	t          *translator
+	rule       *schema.Rule
+	statements []schema.Statement
+
+	protocol        string
+	protocolIndex   int
+	protocolNegated bool
+	// protocolMatched is set when a protocol specific match (e.g. a port) implies the protocol.
+	protocolMatched bool
+
+	target        string
+	isGoto        bool
+	targetOptions map[string]string
+}
+
+// translateRule translates a rule line: `[packets:bytes] -A CHAIN options...`.
+func (t *translator) translateRule(line string) (*schema.Rule, error) {
+	args, err := splitArgs(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && strings.HasPrefix(args[0], "[") {
+		args = args[1:]
+	}
+	if len(args) < 2 || (args[0] != "-A" && args[0] != "--append") {
+		return nil, fmt.Errorf("malformed rule: %s", line)
+	}
+
+	b := &ruleBuilder{
+		t:             t,
+		rule:          &schema.Rule{Family: t.family, Table: t.table.Name, Chain: args[1]},
+		protocolIndex: -1,
+		targetOptions: map[string]string{},
+	}
+	if err := b.parseOptions(args[2:]); err != nil {
+		return nil, err
+	}
+	if err := b.build(); err != nil {
+		return nil, err
+	}
+	return b.rule, nil
+}
+
+func (b *ruleBuilder) parseOptions(args []string) error {
+	negate := false
+	for i := 0; i < len(args); i++ {
+		option := args[i]
+		if option == "!" {
+			negate = true
+			continue
+		}
+
+		if option == "-c" || option == "--set-counters" {
+			// The packets and bytes counters are ignored.
+			i += 2
+			continue
+		}
+
+		value := ""
+		if !isFlagOption(option) {
+			if i+1 >= len(args) {
+				return fmt.Errorf("missing value for option %s", option)
+			}
+			i++
+			value = args[i]
+		}
+
+		op := schema.OperEQ
+		if negate {
+			op = schema.OperNEQ
+		}
+		if err := b.parseOption(option, value, op); err != nil {
+			return err
+		}
+		negate = false
+	}
+	return nil
+}
+
+func isFlagOption(option string) bool {
+	switch option {
+	case "--random", "--random-fully", "--persistent":
+		return true
+	}
+	return false
+}
+
+func (b *ruleBuilder) parseOption(option, value, op string) error {
+	switch option {
+	case "-s", "--source":
+		return b.matchAddress(schema.PayloadFieldIPSAddr, value, op)
+	case "-d", "--destination":
+		return b.matchAddress(schema.PayloadFieldIPDAddr, value, op)
+	case "-i", "--in-interface":
+		b.matchMeta(schema.MetaKeyIifName, interfaceName(value), op)
+	case "-o", "--out-interface":
+		b.matchMeta(schema.MetaKeyOifName, interfaceName(value), op)
+	case "-p", "--protocol":
+		if value != "all" {
+			b.protocol = value
+			b.protocolIndex = len(b.statements)
+			b.protocolNegated = op != schema.OperEQ
+			b.matchMeta(schema.MetaKeyL4Proto, value, op)
+		}
+	case "-m", "--match":
+		if !supportedModules[value] {
+			return untranslatable("unsupported match module %s", value)
+		}
+	case "--dport", "--destination-port", "--sport", "--source-port":
+		return b.matchPort(option, value, op)
+	case "--dports", "--destination-ports", "--sports", "--source-ports":
+		return b.matchPorts(option, value, op)
+	case "--comment":
+		b.rule.Comment = value
+	case "--ctstate", "--state":
+		b.matchCtState(value, op)
+	case "--mark":
+		return b.matchMark(value, op)
+	case "--icmp-type", "--icmpv6-type":
+		return b.matchICMPType(option, value, op)
+	case "-j", "--jump", "-g", "--goto":
+		b.target = value
+		b.isGoto = option == "-g" || option == "--goto"
+	case "--to-ports", "--to-source", "--to-destination", "--random", "--random-fully", "--persistent":
+		b.targetOptions[option] = value
+	default:
+		return untranslatable("unsupported option %s", option)
+	}
+	return nil
+}
+
+// build completes the rule statements with the counter and the target.
+func (b *ruleBuilder) build() error {
+	if b.protocolMatched && !b.protocolNegated && b.protocolIndex >= 0 {
+		// The protocol is implied by the protocol specific matches.
+		b.statements = append(b.statements[:b.protocolIndex], b.statements[b.protocolIndex+1:]...)
+	}
+	b.statements = append(b.statements, schema.Statement{Counter: &schema.Counter{}})
+
+	target, err := b.translateTarget()
+	if err != nil {
+		return err
+	}
+	if target != nil {
+		b.statements = append(b.statements, *target)
+	}
+
+	b.rule.Expr = b.statements
+	return nil
+}
+
+func (b *ruleBuilder) translateTarget() (*schema.Statement, error) {
+	statement := &schema.Statement{}
+	switch b.target {
+	case "":
+		return nil, nil
+	case "ACCEPT":
+		statement.Verdict = schema.Accept()
+	case "DROP":
+		statement.Verdict = schema.Drop()
+	case "RETURN":
+		statement.Verdict = schema.Return()
+	case "MASQUERADE":
+		statement.Masquerade = &schema.Masquerade{
+			Enabled: true,
+			Port:    b.natPorts(b.targetOptions["--to-ports"]),
+			Flags:   b.natFlags(),
+		}
+	case "REDIRECT":
+		statement.Redirect = &schema.Redirect{
+			Enabled: true,
+			Port:    b.natPorts(b.targetOptions["--to-ports"]),
+			Flags:   b.natFlags(),
+		}
+	case "SNAT":
+		addr, port, err := natAddressAndPorts(b.targetOptions["--to-source"])
+		if err != nil {
+			return nil, err
+		}
+		statement.Snat = &schema.Snat{Addr: addr, Port: port, Flags: b.natFlags()}
+	case "DNAT":
+		addr, port, err := natAddressAndPorts(b.targetOptions["--to-destination"])
+		if err != nil {
+			return nil, err
+		}
+		statement.Dnat = &schema.Dnat{Addr: addr, Port: port, Flags: b.natFlags()}
+	default:
+		if !b.t.isChainDefined(b.target) {
+			return nil, untranslatable("unsupported target %s", b.target)
+		}
+		if b.isGoto {
+			statement.Goto = &schema.ToTarget{Target: b.target}
+		} else {
+			statement.Jump = &schema.ToTarget{Target: b.target}
+		}
+	}
+	return statement, nil
+}
+
+// natFlagOptions maps the NAT target options to their flags, in the nftables order.
+var natFlagOptions = []struct{ option, flag string }{
+	{"--random", schema.NATFlagRandom},
+	{"--random-fully", schema.NATFlagFullyRandom},
+	{"--persistent", schema.NATFlagPersistent},
+}
+
+func (b *ruleBuilder) natFlags() *schema.Flags {
+	var flags []string
+	for _, natFlag := range natFlagOptions {
+		if _, exists := b.targetOptions[natFlag.option]; exists {
+			flags = append(flags, natFlag.flag)
+		}
+	}
+	if len(flags) == 0 {
+		return nil
+	}
+	return &schema.Flags{Flags: flags}
+}
+
+func (b *ruleBuilder) natPorts(ports string) *schema.Expression {
+	if ports == "" {
+		return nil
+	}
+	return portsExpression(strings.Replace(ports, "-", ":", 1))
+}
+
+func (b *ruleBuilder) matchAddress(field, value, op string) error {
+	if strings.Contains(value, ",") {
+		return untranslatable("multiple addresses are not supported: %s", value)
+	}
+	expression, err := addressExpression(value)
+	if err != nil {
+		return err
+	}
+	protocol := schema.PayloadProtocolIP4
+	if b.t.family == schema.FamilyIP6 {
+		protocol = schema.PayloadProtocolIP6
+	}
+	b.match(op, schema.Expression{Payload: &schema.Payload{Protocol: protocol, Field: field}}, *expression)
+	return nil
+}
+
+func (b *ruleBuilder) matchMeta(key, value, op string) {
+	b.match(op, schema.Expression{Meta: &schema.Meta{Key: key}}, scalarExpression(value))
+}
+
+func (b *ruleBuilder) matchPort(option, value, op string) error {
+	if b.protocol == "" {
+		return untranslatable("port match %s without a protocol", option)
+	}
+	if b.protocolNegated {
+		return untranslatable("port match %s with a negated protocol", option)
+	}
+	b.protocolMatched = true
+	field := "dport"
+	if strings.HasPrefix(option, "--s") {
+		field = "sport"
+	}
+	b.match(op, schema.Expression{Payload: &schema.Payload{Protocol: b.protocol, Field: field}}, *portsExpression(value))
+	return nil
+}
+
+func (b *ruleBuilder) matchPorts(option, value, op string) error {
+	if b.protocol == "" {
+		return untranslatable("port match %s without a protocol", option)
+	}
+	if b.protocolNegated {
+		return untranslatable("port match %s with a negated protocol", option)
+	}
+	b.protocolMatched = true
+	field := "dport"
+	if strings.HasPrefix(option, "--s") {
+		field = "sport"
+	}
+
+	var elements []schema.Expression
+	for _, port := range strings.Split(value, ",") {
+		elements = append(elements, *portsExpression(port))
+	}
+	left := schema.Expression{Payload: &schema.Payload{Protocol: b.protocol, Field: field}}
+	b.match(op, left, schema.NewSet(elements...))
+	return nil
+}
+
+func (b *ruleBuilder) matchCtState(value, op string) {
+	var states []string
+	for _, state := range strings.Split(value, ",") {
+		states = append(states, strings.ToLower(state))
+	}
+	if op == schema.OperEQ {
+		op = schema.OperIN
+	}
+
+	right := scalarExpression(states[0])
+	if len(states) > 1 {
+		data, _ := json.Marshal(states)
+		right = schema.Expression{RowData: data}
+	}
+	b.match(op, schema.Expression{Ct: &schema.Ct{Key: schema.CtKeyState}}, right)
+}
+
+func (b *ruleBuilder) matchMark(value, op string) error {
+	if strings.Contains(value, "/") {
+		return untranslatable("mark with a mask is not supported: %s", value)
+	}
+	mark, err := strconv.ParseUint(value, 0, 32)
+	if err != nil {
+		return fmt.Errorf("invalid mark %s: %v", value, err)
+	}
+	markValue := float64(mark)
+	b.match(op, schema.Expression{Meta: &schema.Meta{Key: schema.MetaKeyMark}}, schema.Expression{Float64: &markValue})
+	return nil
+}
+
+func (b *ruleBuilder) matchICMPType(option, value, op string) error {
+	if strings.Contains(value, "/") {
+		return untranslatable("icmp type with a code is not supported: %s", value)
+	}
+	protocol := "icmp"
+	if option == "--icmpv6-type" {
+		protocol = "icmpv6"
+	}
+	b.protocolMatched = true
+	b.match(op, schema.Expression{Payload: &schema.Payload{Protocol: protocol, Field: "type"}}, scalarExpression(value))
+	return nil
+}
+
+func (b *ruleBuilder) match(op string, left, right schema.Expression) {
+	b.statements = append(b.statements, schema.Statement{Match: &schema.Match{Op: op, Left: left, Right: right}})
+}
+
+func (t *translator) isChainDefined(name string) bool {
+	// User defined chains are declared at the table header, before any rule.
+	return t.chains[chainKey{table: t.table.Name, name: name}]
+}
+
+// interfaceName converts an iptables interface name to nftables, where the `+` wildcard is `*`.
+func interfaceName(name string) string {
+	if strings.HasSuffix(name, "+") {
+		return strings.TrimSuffix(name, "+") + "*"
+	}
+	return name
+}
+
+// scalarExpression returns a numeric expression when the value is a number, otherwise a string one.
+func scalarExpression(value string) schema.Expression {
+	if number, err := strconv.ParseUint(value, 10, 32); err == nil {
+		n := float64(number)
+		return schema.Expression{Float64: &n}
+	}
+	return schema.Expression{String: &value}
+}
+
+// portsExpression translates a port or a port range (`min:max`, `:max` or `min:`).
+func portsExpression(value string) *schema.Expression {
+	if !strings.Contains(value, ":") {
+		expression := scalarExpression(value)
+		return &expression
+	}
+	bounds := strings.SplitN(value, ":", 2)
+	if bounds[0] == "" {
+		bounds[0] = "0"
+	}
+	if bounds[1] == "" {
+		bounds[1] = "65535"
+	}
+	return rangeExpression(scalarExpression(bounds[0]), scalarExpression(bounds[1]))
+}
+
+func rangeExpression(min, max schema.Expression) *schema.Expression {
+	expression := schema.NewRange(min, max)
+	return &expression
+}
+
+// addressExpression translates an address with an optional mask, as a prefix length or a dotted mask.
+func addressExpression(value string) (*schema.Expression, error) {
+	addr, mask := value, ""
+	if i := strings.Index(value, "/"); i >= 0 {
+		addr, mask = value[:i], value[i+1:]
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, untranslatable("unsupported address %s", value)
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		bits = 8 * net.IPv4len
+	}
+
+	length := bits
+	if mask != "" {
+		var err error
+		if length, err = strconv.Atoi(mask); err != nil {
+			maskIP := net.ParseIP(mask)
+			if maskIP == nil {
+				return nil, fmt.Errorf("invalid address mask %s", value)
+			}
+			if ip.To4() != nil {
+				maskIP = maskIP.To4()
+			}
+			ones, maskBits := net.IPMask(maskIP).Size()
+			if maskBits == 0 {
+				return nil, untranslatable("non-contiguous address mask %s", value)
+			}
+			length = ones
+		}
+	}
+
+	if length == bits {
+		return &schema.Expression{String: &addr}, nil
+	}
+	return &schema.Expression{Prefix: &schema.Prefix{Addr: addr, Len: length}}, nil
+}
+
+// natAddressAndPorts translates a NAT target address, formatted as `addr[-addr][:port[-port]]`.
+// IPv6 addresses with ports are enclosed in brackets: `[addr]:port`.
+func natAddressAndPorts(value string) (*schema.Expression, *schema.Expression, error) {
+	if value == "" {
+		return nil, nil, untranslatable("NAT target without an address")
+	}
+
+	addr, ports := value, ""
+	switch {
+	case strings.HasPrefix(value, "["):
+		end := strings.Index(value, "]")
+		if end < 0 {
+			return nil, nil, fmt.Errorf("invalid NAT address %s", value)
+		}
+		addr = value[1:end]
+		ports = strings.TrimPrefix(value[end+1:], ":")
+	case strings.Count(value, ":") == 1:
+		parts := strings.SplitN(value, ":", 2)
+		addr, ports = parts[0], parts[1]
+	}
+
+	var addrExpression *schema.Expression
+	if bounds := strings.SplitN(addr, "-", 2); len(bounds) == 2 {
+		addrExpression = rangeExpression(schema.Expression{String: &bounds[0]}, schema.Expression{String: &bounds[1]})
+	} else {
+		addrExpression = &schema.Expression{String: &addr}
+	}
+
+	var portExpression *schema.Expression
+	if ports != "" {
+		portExpression = portsExpression(strings.Replace(ports, "-", ":", 1))
+	}
+	return addrExpression, portExpression, nil
+}
+
+// splitArgs splits a rule line to its arguments, honoring double quoted values.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inQuotes, hasArg := false, false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && inQuotes && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+		case c == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteByte(c)
+			hasArg = true
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote: %s", line)
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,213 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+// Package iptables translates iptables rulesets to nftables configuration.
+//
+// The input is the output of `iptables-save` (or `ip6tables-save`) and the result
+// is a configuration which follows the layout of iptables-nft: every iptables table
+// becomes an nftables table with the same name, and the built-in chains become base
+// chains at the standard priorities.
+//
+//	translation, err := iptables.Translate(strings.NewReader(dump), schema.FamilyIP)
+//	for _, rule := range translation.Untranslated {
+//	    log.Printf("line %d: %s (%s)", rule.Line, rule.Rule, rule.Reason)
+//	}
+//	err = nft.ApplyConfig(translation.Config)
+//
+// Rules which use matches or targets without an equivalent in the schema package
+// are not translated and are reported instead.
+package iptables
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	nftconfig "github.com/networkplumbing/go-nft/nft/config"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// Translation is the result of translating an iptables ruleset.
+type Translation struct {
+	Config *nftconfig.Config
+	// Untranslated lists the rules which could not be translated and are missing from the configuration.
+	Untranslated []UntranslatedRule
+}
+
+// UntranslatedRule describes a rule which could not be translated.
+type UntranslatedRule struct {
+	// Line is the line number of the rule in the input (starting from 1).
+	Line   int
+	Rule   string
+	Reason string
+}
+
+type builtinChain struct {
+	chainType string
+	hook      string
+	prio      int
+}
+
+// builtinChains lists the iptables built-in chains per table, with their iptables-nft hooks and priorities.
+var builtinChains = map[string]map[string]builtinChain{
+	"filter": {
+		"INPUT":   {schema.TypeFilter, schema.HookInput, 0},
+		"FORWARD": {schema.TypeFilter, schema.HookForward, 0},
+		"OUTPUT":  {schema.TypeFilter, schema.HookOutput, 0},
+	},
+	"nat": {
+		"PREROUTING":  {schema.TypeNAT, schema.HookPreRouting, -100},
+		"INPUT":       {schema.TypeNAT, schema.HookInput, 100},
+		"OUTPUT":      {schema.TypeNAT, schema.HookOutput, -100},
+		"POSTROUTING": {schema.TypeNAT, schema.HookPostRouting, 100},
+	},
+	"mangle": {
+		"PREROUTING":  {schema.TypeFilter, schema.HookPreRouting, -150},
+		"INPUT":       {schema.TypeFilter, schema.HookInput, -150},
+		"FORWARD":     {schema.TypeFilter, schema.HookForward, -150},
+		"OUTPUT":      {schema.TypeRoute, schema.HookOutput, -150},
+		"POSTROUTING": {schema.TypeFilter, schema.HookPostRouting, -150},
+	},
+	"raw": {
+		"PREROUTING": {schema.TypeFilter, schema.HookPreRouting, -300},
+		"OUTPUT":     {schema.TypeFilter, schema.HookOutput, -300},
+	},
+	"security": {
+		"INPUT":   {schema.TypeFilter, schema.HookInput, 50},
+		"FORWARD": {schema.TypeFilter, schema.HookForward, 50},
+		"OUTPUT":  {schema.TypeFilter, schema.HookOutput, 50},
+	},
+}
+
+// Translate reads an `iptables-save` formatted ruleset and translates it to an nftables configuration.
+// The family is schema.FamilyIP for an `iptables-save` input or schema.FamilyIP6 for an `ip6tables-save` input.
+// An error is returned when the input is malformed, while rules which cannot be translated
+// are listed in the result.
+func Translate(r io.Reader, family string) (*Translation, error) {
+	if family != schema.FamilyIP && family != schema.FamilyIP6 {
+		return nil, fmt.Errorf("unsupported family %q, expecting %q or %q", family, schema.FamilyIP, schema.FamilyIP6)
+	}
+
+	t := &translator{family: family, chains: map[chainKey]bool{}}
+	var tables, chains, rules []schema.Nftable
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "*"):
+			t.table = &schema.Table{Family: family, Name: strings.TrimPrefix(line, "*")}
+			tables = append(tables, schema.Nftable{Table: t.table})
+		case line == "COMMIT":
+			t.table = nil
+		case t.table == nil:
+			return nil, fmt.Errorf("line %d: definition outside of a table: %s", lineNumber, line)
+		case strings.HasPrefix(line, ":"):
+			chain, err := t.translateChain(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			chains = append(chains, schema.Nftable{Chain: chain})
+		default:
+			rule, err := t.translateRule(line)
+			if err != nil {
+				if _, ok := err.(*untranslatableError); !ok {
+					return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+				}
+				t.untranslated = append(t.untranslated, UntranslatedRule{Line: lineNumber, Rule: line, Reason: err.Error()})
+				continue
+			}
+			rules = append(rules, schema.Nftable{Rule: rule})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if t.table != nil {
+		return nil, fmt.Errorf("missing COMMIT for table %s", t.table.Name)
+	}
+
+	config := nftconfig.New()
+	config.Nftables = append(config.Nftables, tables...)
+	config.Nftables = append(config.Nftables, chains...)
+	config.Nftables = append(config.Nftables, rules...)
+	return &Translation{Config: config, Untranslated: t.untranslated}, nil
+}
+
+type translator struct {
+	family       string
+	table        *schema.Table
+	chains       map[chainKey]bool
+	untranslated []UntranslatedRule
+}
+
+type chainKey struct {
+	table string
+	name  string
+}
+
+// untranslatableError reports a valid rule which has no translation.
+type untranslatableError struct {
+	reason string
+}
+
+func (e *untranslatableError) Error() string {
+	return e.reason
+}
+
+func untranslatable(format string, a ...interface{}) error {
+	return &untranslatableError{reason: fmt.Sprintf(format, a...)}
+}
+
+// translateChain translates a chain definition line: `:NAME POLICY [packets:bytes]`.
+func (t *translator) translateChain(line string) (*schema.Chain, error) {
+	fields := strings.Fields(strings.TrimPrefix(line, ":"))
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("malformed chain definition: %s", line)
+	}
+	name, policy := fields[0], fields[1]
+
+	chain := &schema.Chain{Family: t.family, Table: t.table.Name, Name: name}
+	t.chains[chainKey{table: t.table.Name, name: name}] = true
+	if policy == "-" {
+		return chain, nil
+	}
+
+	builtin, isBuiltin := builtinChains[t.table.Name][name]
+	if !isBuiltin {
+		return nil, fmt.Errorf("policy defined for a non built-in chain %s in table %s", name, t.table.Name)
+	}
+	prio := builtin.prio
+	chain.Type, chain.Hook, chain.Prio = builtin.chainType, builtin.hook, &prio
+
+	switch policy {
+	case "ACCEPT":
+		chain.Policy = schema.PolicyAccept
+	case "DROP":
+		chain.Policy = schema.PolicyDrop
+	default:
+		return nil, fmt.Errorf("unsupported chain policy %s", policy)
+	}
+	return chain, nil
+}
@@ -0,0 +1,185 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package iptables_test
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/nft/iptables"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+const iptablesSaveDump = `# Generated by iptables-save v1.8.7 on Thu Jan  1 00:00:00 2021
+*filter
+:INPUT DROP [10:1000]
+:FORWARD ACCEPT [0:0]
+:OUTPUT ACCEPT [0:0]
+:ingress - [0:0]
+[5:500] -A INPUT -i eth0+ -j ingress
+-A INPUT -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+-A ingress -s 10.0.0.0/255.255.255.0 -p tcp -m tcp --dport 22 -m comment --comment "allow ssh" -j ACCEPT
+-A ingress ! -s 10.0.0.66/32 -p tcp -m multiport --dports 80,443,8000:8080 -j ACCEPT
+-A ingress -p tcp -m tcp --tcp-flags SYN,ACK SYN -j DROP
+-A ingress -j REJECT --reject-with icmp-port-unreachable
+COMMIT
+*nat
+:PREROUTING ACCEPT [0:0]
+:POSTROUTING ACCEPT [0:0]
+-A PREROUTING -d 192.168.0.1/32 -p udp -m udp --dport 53 -j DNAT --to-destination 10.0.0.53:5353
+-A POSTROUTING -o eth1 -j MASQUERADE --random-fully
+COMMIT
+`
+
+func TestTranslate(t *testing.T) {
+	t.Run("Translate an iptables-save dump", func(t *testing.T) {
+		translation, err := iptables.Translate(strings.NewReader(iptablesSaveDump), schema.FamilyIP)
+		assert.NoError(t, err)
+
+		expectedUntranslated := []iptables.UntranslatedRule{
+			{
+				Line:   11,
+				Rule:   "-A ingress -p tcp -m tcp --tcp-flags SYN,ACK SYN -j DROP",
+				Reason: "unsupported option --tcp-flags",
+			},
+			{
+				Line:   12,
+				Rule:   "-A ingress -j REJECT --reject-with icmp-port-unreachable",
+				Reason: "unsupported option --reject-with",
+			},
+		}
+		assert.Equal(t, expectedUntranslated, translation.Untranslated)
+
+		data, err := translation.Config.ToJSON()
+		assert.NoError(t, err)
+		assert.JSONEq(t, expectedTranslationJSON, string(data))
+	})
+
+	t.Run("Translate an ip6tables-save dump", func(t *testing.T) {
+		dump := "*filter\n:INPUT ACCEPT [0:0]\n-A INPUT -s fd00::/64 -p icmpv6 -m icmp6 --icmpv6-type echo-request -j DROP\nCOMMIT\n"
+		translation, err := iptables.Translate(strings.NewReader(dump), schema.FamilyIP6)
+		assert.NoError(t, err)
+		assert.Empty(t, translation.Untranslated)
+
+		data, err := translation.Config.ToJSON()
+		assert.NoError(t, err)
+		expected := `{"nftables":[
+			{"table":{"family":"ip6","name":"filter"}},
+			{"chain":{"family":"ip6","table":"filter","name":"INPUT","type":"filter","hook":"input","prio":0,"policy":"accept"}},
+			{"rule":{"family":"ip6","table":"filter","chain":"INPUT","expr":[
+				{"match":{"op":"==","left":{"payload":{"protocol":"ip6","field":"saddr"}},"right":{"prefix":{"addr":"fd00::","len":64}}}},
+				{"match":{"op":"==","left":{"payload":{"protocol":"icmpv6","field":"type"}},"right":"echo-request"}},
+				{"counter":{"packets":0,"bytes":0}},
+				{"drop":null}
+			]}}
+		]}`
+		assert.JSONEq(t, expected, string(data))
+	})
+
+	t.Run("Translate a negated protocol match", func(t *testing.T) {
+		dump := "*filter\n:INPUT ACCEPT [0:0]\n-A INPUT ! -p tcp -j DROP\n-A INPUT ! -p udp --dport 53 -j DROP\nCOMMIT\n"
+		translation, err := iptables.Translate(strings.NewReader(dump), schema.FamilyIP)
+		assert.NoError(t, err)
+
+		expectedUntranslated := []iptables.UntranslatedRule{{
+			Line:   4,
+			Rule:   "-A INPUT ! -p udp --dport 53 -j DROP",
+			Reason: "port match --dport with a negated protocol",
+		}}
+		assert.Equal(t, expectedUntranslated, translation.Untranslated)
+
+		data, err := translation.Config.ToJSON()
+		assert.NoError(t, err)
+		expected := `{"nftables":[
+			{"table":{"family":"ip","name":"filter"}},
+			{"chain":{"family":"ip","table":"filter","name":"INPUT","type":"filter","hook":"input","prio":0,"policy":"accept"}},
+			{"rule":{"family":"ip","table":"filter","chain":"INPUT","expr":[
+				{"match":{"op":"!=","left":{"meta":{"key":"l4proto"}},"right":"tcp"}},
+				{"counter":{"packets":0,"bytes":0}},
+				{"drop":null}
+			]}}
+		]}`
+		assert.JSONEq(t, expected, string(data))
+	})
+
+	t.Run("Unsupported family", func(t *testing.T) {
+		_, err := iptables.Translate(strings.NewReader(""), schema.FamilyINET)
+		assert.Error(t, err)
+	})
+
+	t.Run("Malformed dumps", func(t *testing.T) {
+		for _, dump := range []string{
+			":INPUT ACCEPT [0:0]\n",
+			"*filter\n:INPUT ACCEPT [0:0]\n",
+			"*filter\n:INPUT\nCOMMIT\n",
+			"*filter\n-I INPUT -j ACCEPT\nCOMMIT\n",
+			"*filter\n-A INPUT -m comment --comment \"unterminated\nCOMMIT\n",
+		} {
+			_, err := iptables.Translate(strings.NewReader(dump), schema.FamilyIP)
+			assert.Error(t, err, dump)
+		}
+	})
+}
+
+const expectedTranslationJSON = `{"nftables":[
+	{"table":{"family":"ip","name":"filter"}},
+	{"table":{"family":"ip","name":"nat"}},
+	{"chain":{"family":"ip","table":"filter","name":"INPUT","type":"filter","hook":"input","prio":0,"policy":"drop"}},
+	{"chain":{"family":"ip","table":"filter","name":"FORWARD","type":"filter","hook":"forward","prio":0,"policy":"accept"}},
+	{"chain":{"family":"ip","table":"filter","name":"OUTPUT","type":"filter","hook":"output","prio":0,"policy":"accept"}},
+	{"chain":{"family":"ip","table":"filter","name":"ingress"}},
+	{"chain":{"family":"ip","table":"nat","name":"PREROUTING","type":"nat","hook":"prerouting","prio":-100,"policy":"accept"}},
+	{"chain":{"family":"ip","table":"nat","name":"POSTROUTING","type":"nat","hook":"postrouting","prio":100,"policy":"accept"}},
+	{"rule":{"family":"ip","table":"filter","chain":"INPUT","expr":[
+		{"match":{"op":"==","left":{"meta":{"key":"iifname"}},"right":"eth0*"}},
+		{"counter":{"packets":0,"bytes":0}},
+		{"jump":{"target":"ingress"}}
+	]}},
+	{"rule":{"family":"ip","table":"filter","chain":"INPUT","expr":[
+		{"match":{"op":"in","left":{"ct":{"key":"state"}},"right":["related","established"]}},
+		{"counter":{"packets":0,"bytes":0}},
+		{"accept":null}
+	]}},
+	{"rule":{"family":"ip","table":"filter","chain":"ingress","comment":"allow ssh","expr":[
+		{"match":{"op":"==","left":{"payload":{"protocol":"ip","field":"saddr"}},"right":{"prefix":{"addr":"10.0.0.0","len":24}}}},
+		{"match":{"op":"==","left":{"payload":{"protocol":"tcp","field":"dport"}},"right":22}},
+		{"counter":{"packets":0,"bytes":0}},
+		{"accept":null}
+	]}},
+	{"rule":{"family":"ip","table":"filter","chain":"ingress","expr":[
+		{"match":{"op":"!=","left":{"payload":{"protocol":"ip","field":"saddr"}},"right":"10.0.0.66"}},
+		{"match":{"op":"==","left":{"payload":{"protocol":"tcp","field":"dport"}},"right":{"set":[80,443,{"range":[8000,8080]}]}}},
+		{"counter":{"packets":0,"bytes":0}},
+		{"accept":null}
+	]}},
+	{"rule":{"family":"ip","table":"nat","chain":"PREROUTING","expr":[
+		{"match":{"op":"==","left":{"payload":{"protocol":"ip","field":"daddr"}},"right":"192.168.0.1"}},
+		{"match":{"op":"==","left":{"payload":{"protocol":"udp","field":"dport"}},"right":53}},
+		{"counter":{"packets":0,"bytes":0}},
+		{"dnat":{"addr":"10.0.0.53","port":5353}}
+	]}},
+	{"rule":{"family":"ip","table":"nat","chain":"POSTROUTING","expr":[
+		{"match":{"op":"==","left":{"meta":{"key":"oifname"}},"right":"eth1"}},
+		{"counter":{"packets":0,"bytes":0}},
+		{"masquerade":{"flags":"fully-random"}}
+	]}}
+]}`
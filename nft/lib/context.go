@@ -0,0 +1,323 @@
+//go:build cgo
+// +build cgo
+
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+package lib
+
+// #cgo CFLAGS: -g -Wall
+// #cgo LDFLAGS: -lnftables
+// #include <nftables/libnftables.h>
+// #include <stdlib.h>
+// #include <string.h>
+import "C"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// OutputFlags controls the libnftables output.
+// The JSON output is always enabled, as the output is parsed to a nftables config structure.
+type OutputFlags uint
+
+const (
+	OutputHandle        OutputFlags = C.NFT_CTX_OUTPUT_HANDLE
+	OutputEcho          OutputFlags = C.NFT_CTX_OUTPUT_ECHO
+	OutputStateless     OutputFlags = C.NFT_CTX_OUTPUT_STATELESS
+	OutputTerse         OutputFlags = C.NFT_CTX_OUTPUT_TERSE
+	OutputGUID          OutputFlags = C.NFT_CTX_OUTPUT_GUID
+	OutputNumericProto  OutputFlags = C.NFT_CTX_OUTPUT_NUMERIC_PROTO
+	OutputNumericPrio   OutputFlags = C.NFT_CTX_OUTPUT_NUMERIC_PRIO
+	OutputNumericSymbol OutputFlags = C.NFT_CTX_OUTPUT_NUMERIC_SYMBOL
+	OutputNumericTime   OutputFlags = C.NFT_CTX_OUTPUT_NUMERIC_TIME
+	OutputNumericAll    OutputFlags = C.NFT_CTX_OUTPUT_NUMERIC_ALL
+
+	outputJSON OutputFlags = C.NFT_CTX_OUTPUT_JSON
+)
+
+// DebugFlags controls the libnftables debug output.
+type DebugFlags uint
+
+const (
+	DebugScanner    DebugFlags = C.NFT_DEBUG_SCANNER
+	DebugParser     DebugFlags = C.NFT_DEBUG_PARSER
+	DebugEvaluation DebugFlags = C.NFT_DEBUG_EVALUATION
+	DebugNetlink    DebugFlags = C.NFT_DEBUG_NETLINK
+	DebugMnl        DebugFlags = C.NFT_DEBUG_MNL
+	DebugProtoCtx   DebugFlags = C.NFT_DEBUG_PROTO_CTX
+	DebugSegtree    DebugFlags = C.NFT_DEBUG_SEGTREE
+)
+
+// Context owns a libnftables context, reused by all the commands it runs.
+// It is safe for concurrent use, commands are serialized internally.
+// The context should be released with Close when no longer needed.
+type Context struct {
+	mu          sync.Mutex
+	nft         *C.struct_nft_ctx
+	outputFlags OutputFlags
+}
+
+// NewContext creates a libnftables context with the given output flags.
+func NewContext(flags OutputFlags) (*Context, error) {
+	nftCtx := C.nft_ctx_new(C.NFT_CTX_DEFAULT)
+	if nftCtx == nil {
+		return nil, fmt.Errorf("failed creating the nftables context")
+	}
+
+	if rc := C.nft_ctx_buffer_output(nftCtx); rc != C.EXIT_SUCCESS {
+		C.nft_ctx_free(nftCtx)
+		return nil, fmt.Errorf("failed enabling output buffering (rc=%d)", rc)
+	}
+	if rc := C.nft_ctx_buffer_error(nftCtx); rc != C.EXIT_SUCCESS {
+		C.nft_ctx_free(nftCtx)
+		return nil, fmt.Errorf("failed enabling error buffering (rc=%d)", rc)
+	}
+
+	c := &Context{nft: nftCtx}
+	c.setOutputFlags(flags)
+	return c, nil
+}
+
+// OutputFlags returns the output flags of the context.
+func (c *Context) OutputFlags() OutputFlags {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.outputFlags
+}
+
+// SetOutputFlags sets the output flags of the context, used by the following commands.
+func (c *Context) SetOutputFlags(flags OutputFlags) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nft != nil {
+		c.setOutputFlags(flags)
+	}
+}
+
+// DebugFlags returns the debug flags of the context.
+func (c *Context) DebugFlags() DebugFlags {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nft == nil {
+		return 0
+	}
+	return DebugFlags(C.nft_ctx_output_get_debug(c.nft))
+}
+
+// SetDebugFlags sets the debug flags of the context, used by the following commands.
+// The debug output is collected with the command errors.
+func (c *Context) SetDebugFlags(flags DebugFlags) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nft != nil {
+		C.nft_ctx_output_set_debug(c.nft, C.uint(flags))
+	}
+}
+
+// Close releases the libnftables context.
+// Commands which run after the context is closed fail.
+func (c *Context) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nft != nil {
+		C.nft_ctx_free(c.nft)
+		c.nft = nil
+	}
+	return nil
+}
+
+// ReadConfig loads the nftables configuration from the system and
+// returns it as a nftables config structure.
+func (c *Context) ReadConfig(filterCommands ...string) (*nft.Config, error) {
+	return c.ReadConfigContext(context.Background(), filterCommands...)
+}
+
+// ReadConfigContext loads the nftables configuration from the system and
+// returns it as a nftables config structure.
+// When the context is done before the command completes, the context error is returned
+// while the command completes in the background.
+func (c *Context) ReadConfigContext(ctx context.Context, filterCommands ...string) (*nft.Config, error) {
+	whatToList := cmdRuleset
+	if len(filterCommands) > 0 {
+		whatToList = strings.Join(filterCommands, " ")
+	}
+	stdout, err := c.runCmdContext(ctx, fmt.Sprintf("%s %s", cmdList, whatToList), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	config := nft.NewConfig()
+	if err := config.FromJSON(stdout); err != nil {
+		return nil, fmt.Errorf("failed to list ruleset: %v", err)
+	}
+
+	return config, nil
+}
+
+// ResetContext resets the stateful objects selected by the reset command (e.g. `counters table ip mytable`)
+// and returns them, with their values before the reset, as a nftables config structure.
+// When the context is done before the command completes, the context error is returned
+// while the command completes in the background, i.e. the objects may still be reset.
+func (c *Context) ResetContext(ctx context.Context, resetCommand ...string) (*nft.Config, error) {
+	stdout, err := c.runCmdContext(ctx, fmt.Sprintf("%s %s", cmdReset, strings.Join(resetCommand, " ")), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	config := nft.NewConfig()
+	if err := config.FromJSON(stdout); err != nil {
+		return nil, fmt.Errorf("failed to parse reset: %v", err)
+	}
+
+	return config, nil
+}
+
+// ResetCounters resets the counters in the given scope and returns their values before the reset.
+func (c *Context) ResetCounters(ctx context.Context, scope nft.ResetScope) ([]nft.CounterValue, error) {
+	return nft.ResetCountersWith(ctx, scope, c.ResetContext)
+}
+
+// ResetQuotas resets the named quotas in the given scope and returns their values before the reset.
+func (c *Context) ResetQuotas(ctx context.Context, scope nft.ResetScope) ([]schema.NamedQuota, error) {
+	return nft.ResetQuotasWith(ctx, scope, c.ResetContext)
+}
+
+// ApplyConfig applies the given nftables config on the system.
+func (c *Context) ApplyConfig(config *nft.Config) error {
+	return c.ApplyConfigContext(context.Background(), config)
+}
+
+// ApplyConfigContext applies the given nftables config on the system.
+// When the context is done before the command completes, the context error is returned
+// while the command completes in the background, i.e. the config may still be applied.
+func (c *Context) ApplyConfigContext(ctx context.Context, config *nft.Config) error {
+	data, err := config.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	if _, err = c.runCmdContext(ctx, string(data), 0); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ApplyConfigEcho applies the given nftables config on the system, echoing
+// back the added elements with their assigned handles.
+// The echo and handle output flags are enabled for this command only.
+func (c *Context) ApplyConfigEcho(config *nft.Config) (*nft.Config, error) {
+	return c.ApplyConfigEchoContext(context.Background(), config)
+}
+
+// ApplyConfigEchoContext applies the given nftables config on the system, echoing
+// back the added elements with their assigned handles.
+// When the context is done before the command completes, the context error is returned
+// while the command completes in the background, i.e. the config may still be applied.
+func (c *Context) ApplyConfigEchoContext(ctx context.Context, config *nft.Config) (*nft.Config, error) {
+	data, err := config.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := c.runCmdContext(ctx, string(data), OutputEcho|OutputHandle)
+	if err != nil {
+		return nil, err
+	}
+
+	echoConfig := nft.NewConfig()
+	if err := echoConfig.FromJSON(stdout); err != nil {
+		return nil, fmt.Errorf("failed to parse echo: %v", err)
+	}
+
+	return echoConfig, nil
+}
+
+// RunCmd runs the given command (in the nftables syntax or JSON) and returns its output.
+func (c *Context) RunCmd(cmd string) ([]byte, error) {
+	return c.runCmd(cmd, 0)
+}
+
+// runCmdContext runs the command on a dedicated goroutine, returning as soon as the context is done.
+// libnftables calls cannot be interrupted, therefore the command keeps running until it completes.
+func (c *Context) runCmdContext(ctx context.Context, cmd string, extraFlags OutputFlags) ([]byte, error) {
+	if ctx.Done() == nil {
+		return c.runCmd(cmd, extraFlags)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		output []byte
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := c.runCmd(cmd, extraFlags)
+		done <- result{output: output, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runCmd runs the command with the extra output flags added to the context ones.
+func (c *Context) runCmd(cmd string, extraFlags OutputFlags) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.nft == nil {
+		return nil, fmt.Errorf("nftables context is closed")
+	}
+
+	if extraFlags != 0 {
+		C.nft_ctx_output_set_flags(c.nft, C.uint(c.outputFlags|outputJSON|extraFlags))
+		defer c.setOutputFlags(c.outputFlags)
+	}
+
+	buf := C.CString(cmd)
+	defer C.free(unsafe.Pointer(buf))
+
+	rc := C.nft_run_cmd_from_buffer(c.nft, buf)
+
+	// Reading the buffers resets them for the next command.
+	output := C.GoString(C.nft_ctx_get_output_buffer(c.nft))
+	errMsg := C.GoString(C.nft_ctx_get_error_buffer(c.nft))
+	if rc != C.EXIT_SUCCESS {
+		return nil, fmt.Errorf("failed running cmd (rc=%d): %s", rc, errMsg)
+	}
+
+	return []byte(output), nil
+}
+
+func (c *Context) setOutputFlags(flags OutputFlags) {
+	c.outputFlags = flags
+	C.nft_ctx_output_set_flags(c.nft, C.uint(flags|outputJSON))
+}
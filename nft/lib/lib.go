@@ -21,123 +21,118 @@
  */
 package lib
 
-// #cgo CFLAGS: -g -Wall
-// #cgo LDFLAGS: -lnftables
-// #include <nftables/libnftables.h>
-// #include <stdlib.h>
-// #include <string.h>
-import "C"
 import (
-	"fmt"
-	"strings"
-	"unsafe"
+	"context"
+	"sync"
 
 	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
 )
 
 const (
 	cmdList    = "list"
+	cmdReset   = "reset"
 	cmdRuleset = "ruleset"
 )
 
+var (
+	defaultContext     *Context
+	defaultContextErr  error
+	defaultContextOnce sync.Once
+)
+
+// DefaultContext returns the libnftables context used by the package level functions.
+// It is created on first use and shared by all callers, it should not be closed.
+func DefaultContext() (*Context, error) {
+	defaultContextOnce.Do(func() {
+		defaultContext, defaultContextErr = NewContext(0)
+	})
+	return defaultContext, defaultContextErr
+}
+
 // ReadConfig loads the nftables configuration from the system and
 // returns it as a nftables config structure.
 // The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
 func ReadConfig(filterCommands ...string) (*nft.Config, error) {
-	whatToList := cmdRuleset
-	if len(filterCommands) > 0 {
-		whatToList = strings.Join(filterCommands, " ")
-	}
-	stdout, err := libNftablesRunCmd(fmt.Sprintf("%s %s", cmdList, whatToList))
+	ctx, err := DefaultContext()
 	if err != nil {
 		return nil, err
 	}
+	return ctx.ReadConfig(filterCommands...)
+}
 
-	config := nft.NewConfig()
-	if err := config.FromJSON(stdout); err != nil {
-		return nil, fmt.Errorf("failed to list ruleset: %v", err)
+// ReadConfigContext loads the nftables configuration from the system and
+// returns it as a nftables config structure.
+// When the context is done before the command completes, the context error is returned
+// while the command completes in the background.
+func ReadConfigContext(ctx context.Context, filterCommands ...string) (*nft.Config, error) {
+	nftCtx, err := DefaultContext()
+	if err != nil {
+		return nil, err
 	}
-
-	return config, nil
+	return nftCtx.ReadConfigContext(ctx, filterCommands...)
 }
 
 // ApplyConfig applies the given nftables config on the system.
 // The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
 func ApplyConfig(c *nft.Config) error {
-	data, err := c.ToJSON()
+	ctx, err := DefaultContext()
 	if err != nil {
 		return err
 	}
+	return ctx.ApplyConfig(c)
+}
 
-	if _, err = libNftablesRunCmd(string(data)); err != nil {
+// ApplyConfigContext applies the given nftables config on the system.
+// When the context is done before the command completes, the context error is returned
+// while the command completes in the background, i.e. the config may still be applied.
+func ApplyConfigContext(ctx context.Context, c *nft.Config) error {
+	nftCtx, err := DefaultContext()
+	if err != nil {
 		return err
 	}
-
-	return nil
+	return nftCtx.ApplyConfigContext(ctx, c)
 }
 
 // ApplyConfigEcho applies the given nftables config on the system, echoing
 // back the added elements with their assigned handles
 // The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
 func ApplyConfigEcho(c *nft.Config) (*nft.Config, error) {
-	data, err := c.ToJSON()
+	ctx, err := DefaultContext()
 	if err != nil {
 		return nil, err
 	}
+	return ctx.ApplyConfigEcho(c)
+}
 
-	stdout, err := libNftablesRunCmdEcho(string(data))
+// ApplyConfigEchoContext applies the given nftables config on the system, echoing
+// back the added elements with their assigned handles.
+// When the context is done before the command completes, the context error is returned
+// while the command completes in the background, i.e. the config may still be applied.
+func ApplyConfigEchoContext(ctx context.Context, c *nft.Config) (*nft.Config, error) {
+	nftCtx, err := DefaultContext()
 	if err != nil {
 		return nil, err
 	}
-
-	config := nft.NewConfig()
-	if err := config.FromJSON(stdout); err != nil {
-		return nil, fmt.Errorf("failed to parse echo: %v", err)
-	}
-
-	return config, nil
+	return nftCtx.ApplyConfigEchoContext(ctx, c)
 }
 
-func libNftablesRunCmd(cmd string) ([]byte, error) {
-	nft := C.nft_ctx_new(C.NFT_CTX_DEFAULT)
-	defer C.nft_ctx_free(nft)
-
-	C.nft_ctx_output_set_flags(nft, C.NFT_CTX_OUTPUT_JSON)
-
-	return libNftablesRunCmdContext(nft, cmd)
-}
-
-func libNftablesRunCmdEcho(cmd string) ([]byte, error) {
-	nft := C.nft_ctx_new(C.NFT_CTX_DEFAULT)
-	defer C.nft_ctx_free(nft)
-
-	C.nft_ctx_output_set_flags(nft, C.NFT_CTX_OUTPUT_JSON|C.NFT_CTX_OUTPUT_ECHO|C.NFT_CTX_OUTPUT_HANDLE)
-
-	return libNftablesRunCmdContext(nft, cmd)
-}
-
-func libNftablesRunCmdContext(nft *C.struct_nft_ctx, cmd string) ([]byte, error) {
-
-	buf := C.CString(cmd)
-	defer C.free(unsafe.Pointer(buf))
-
-	rc := C.nft_ctx_buffer_output(nft)
-	if rc != C.EXIT_SUCCESS {
-		return nil, fmt.Errorf("failed enabling output buffering (rc=%d)", rc)
-	}
-
-	rc = C.nft_ctx_buffer_error(nft)
-	if rc != C.EXIT_SUCCESS {
-		return nil, fmt.Errorf("failed enabling error buffering (rc=%d)", rc)
+// ResetCounters resets the counters in the given scope and returns their values before the reset.
+// The system is expected to have nftables enabled in the kernel.
+func ResetCounters(ctx context.Context, scope nft.ResetScope) ([]nft.CounterValue, error) {
+	nftCtx, err := DefaultContext()
+	if err != nil {
+		return nil, err
 	}
+	return nftCtx.ResetCounters(ctx, scope)
+}
 
-	rc = C.nft_run_cmd_from_buffer(nft, buf)
-	if rc != C.EXIT_SUCCESS {
-		errMsg := C.nft_ctx_get_error_buffer(nft)
-		return nil, fmt.Errorf("failed running cmd (rc=%d): %s", rc, C.GoString(errMsg))
+// ResetQuotas resets the named quotas in the given scope and returns their values before the reset.
+// The system is expected to have nftables enabled in the kernel.
+func ResetQuotas(ctx context.Context, scope nft.ResetScope) ([]schema.NamedQuota, error) {
+	nftCtx, err := DefaultContext()
+	if err != nil {
+		return nil, err
 	}
-
-	config := C.nft_ctx_get_output_buffer(nft)
-	configLen := C.int(C.strlen(config))
-	return C.GoBytes(unsafe.Pointer(config), configLen), nil
+	return nftCtx.ResetQuotas(ctx, scope)
 }
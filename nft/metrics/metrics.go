@@ -0,0 +1,317 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+// Package metrics collects the nftables counters and exposes them as metrics.
+//
+// The rule counters are keyed by the rule table, chain, comment and handle, while the
+// named counter objects are keyed by their table and name.
+// The Collector follows the shape of the prometheus.Collector interface, without depending on
+// the Prometheus client library. It can be adapted with a few lines:
+//
+//	func (a *adapter) Collect(ch chan<- prometheus.Metric) {
+//		for _, m := range a.collector.Metrics() {
+//			desc := prometheus.NewDesc(m.Desc.Name, m.Desc.Help, m.Desc.Labels, nil)
+//			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, m.Value, m.LabelValues...)
+//		}
+//	}
+//
+// Alternatively, WriteText writes the metrics in the Prometheus text exposition format.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/networkplumbing/go-nft/nft"
+	nftconfig "github.com/networkplumbing/go-nft/nft/config"
+)
+
+// Desc describes a metric.
+type Desc struct {
+	Name   string
+	Help   string
+	Labels []string
+}
+
+// Metric is a counter value, with the values of its description labels.
+type Metric struct {
+	Desc        *Desc
+	LabelValues []string
+	Value       float64
+}
+
+var (
+	RulePacketsDesc = &Desc{
+		Name:   "nftables_rule_packets_total",
+		Help:   "Packets counted by the rule counters.",
+		Labels: []string{"family", "table", "chain", "comment", "handle"},
+	}
+	RuleBytesDesc = &Desc{
+		Name:   "nftables_rule_bytes_total",
+		Help:   "Bytes counted by the rule counters.",
+		Labels: []string{"family", "table", "chain", "comment", "handle"},
+	}
+	CounterPacketsDesc = &Desc{
+		Name:   "nftables_counter_packets_total",
+		Help:   "Packets counted by the named counters.",
+		Labels: []string{"family", "table", "name"},
+	}
+	CounterBytesDesc = &Desc{
+		Name:   "nftables_counter_bytes_total",
+		Help:   "Bytes counted by the named counters.",
+		Labels: []string{"family", "table", "name"},
+	}
+)
+
+// RuleCounter holds the counter values of a rule.
+type RuleCounter struct {
+	Family  string
+	Table   string
+	Chain   string
+	Comment string
+	Handle  int
+	Packets int
+	Bytes   int
+}
+
+// NamedCounter holds the values of a named counter object.
+type NamedCounter struct {
+	Family  string
+	Table   string
+	Name    string
+	Packets int
+	Bytes   int
+}
+
+// Counters is the set of counters read from a configuration.
+type Counters struct {
+	Timestamp time.Time
+	Rules     []RuleCounter
+	Named     []NamedCounter
+}
+
+// ExtractCounters returns the counters included in the configuration.
+// Rules without a counter statement are skipped, while the values of multiple
+// counter statements in the same rule are summed.
+// Counters which reference a named counter object are counted by the object.
+func ExtractCounters(c *nftconfig.Config) *Counters {
+	counters := &Counters{Timestamp: time.Now().UTC()}
+	for _, nftable := range c.Nftables {
+		if rule := nftable.Rule; rule != nil {
+			ruleCounter, hasCounter := RuleCounter{
+				Family:  rule.Family,
+				Table:   rule.Table,
+				Chain:   rule.Chain,
+				Comment: rule.Comment,
+			}, false
+			if rule.Handle != nil {
+				ruleCounter.Handle = *rule.Handle
+			}
+			for _, statement := range rule.Expr {
+				if statement.Counter != nil && statement.Counter.Name == "" && statement.Counter.Ref == nil {
+					ruleCounter.Packets += statement.Counter.Packets
+					ruleCounter.Bytes += statement.Counter.Bytes
+					hasCounter = true
+				}
+			}
+			if hasCounter {
+				counters.Rules = append(counters.Rules, ruleCounter)
+			}
+		}
+		if counter := nftable.Counter; counter != nil {
+			counters.Named = append(counters.Named, NamedCounter{
+				Family:  counter.Family,
+				Table:   counter.Table,
+				Name:    counter.Name,
+				Packets: counter.Packets,
+				Bytes:   counter.Bytes,
+			})
+		}
+	}
+	return counters
+}
+
+// Metrics returns the counters as metrics.
+func (c *Counters) Metrics() []Metric {
+	var metrics []Metric
+	for _, rule := range c.Rules {
+		labels := []string{rule.Family, rule.Table, rule.Chain, rule.Comment, strconv.Itoa(rule.Handle)}
+		metrics = append(metrics,
+			Metric{Desc: RulePacketsDesc, LabelValues: labels, Value: float64(rule.Packets)},
+			Metric{Desc: RuleBytesDesc, LabelValues: labels, Value: float64(rule.Bytes)},
+		)
+	}
+	for _, counter := range c.Named {
+		labels := []string{counter.Family, counter.Table, counter.Name}
+		metrics = append(metrics,
+			Metric{Desc: CounterPacketsDesc, LabelValues: labels, Value: float64(counter.Packets)},
+			Metric{Desc: CounterBytesDesc, LabelValues: labels, Value: float64(counter.Bytes)},
+		)
+	}
+	return metrics
+}
+
+// ReadFunc reads the configuration the counters are extracted from.
+type ReadFunc func(ctx context.Context) (*nftconfig.Config, error)
+
+// Collector periodically reads the nftables configuration and keeps its latest counters.
+type Collector struct {
+	read     ReadFunc
+	interval time.Duration
+
+	mu       sync.Mutex
+	counters *Counters
+	err      error
+}
+
+// DefaultCollectorInterval is the interval at which a collector reads the configuration,
+// when it is created with a non-positive interval.
+const DefaultCollectorInterval = 30 * time.Second
+
+// NewCollector returns a collector which reads the configuration at the given interval.
+// A non-positive interval is replaced by DefaultCollectorInterval.
+// A nil read function reads the whole ruleset from the system.
+func NewCollector(interval time.Duration, read ReadFunc) *Collector {
+	if interval <= 0 {
+		interval = DefaultCollectorInterval
+	}
+	if read == nil {
+		read = func(ctx context.Context) (*nftconfig.Config, error) {
+			return nft.ReadConfigContext(ctx)
+		}
+	}
+	return &Collector{read: read, interval: interval, counters: &Counters{}}
+}
+
+// Run reads the counters immediately and then at every interval, until the context is done.
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.Update(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update reads the configuration and replaces the collected counters.
+// On failure the previous counters are kept and the error is reported by Counters.
+func (c *Collector) Update(ctx context.Context) error {
+	config, err := c.read(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+	if err != nil {
+		return err
+	}
+	c.counters = ExtractCounters(config)
+	return nil
+}
+
+// Counters returns the latest collected counters and the error of the latest read, if any.
+func (c *Collector) Counters() (*Counters, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counters, c.err
+}
+
+// Metrics returns the latest collected counters as metrics.
+func (c *Collector) Metrics() []Metric {
+	counters, _ := c.Counters()
+	return counters.Metrics()
+}
+
+// Describe sends the descriptions of the collected metrics, as prometheus.Collector does.
+func (c *Collector) Describe(ch chan<- *Desc) {
+	for _, desc := range []*Desc{RulePacketsDesc, RuleBytesDesc, CounterPacketsDesc, CounterBytesDesc} {
+		ch <- desc
+	}
+}
+
+// Collect sends the latest collected metrics, as prometheus.Collector does.
+func (c *Collector) Collect(ch chan<- Metric) {
+	for _, metric := range c.Metrics() {
+		ch <- metric
+	}
+}
+
+// WriteText writes the latest collected metrics in the Prometheus text exposition format.
+func (c *Collector) WriteText(w io.Writer) error {
+	return WriteText(w, c.Metrics())
+}
+
+// ServeHTTP serves the latest collected metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := c.WriteText(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format, grouped by their description.
+func WriteText(w io.Writer, metrics []Metric) error {
+	var descs []*Desc
+	byDesc := map[*Desc][]Metric{}
+	for _, metric := range metrics {
+		if _, exists := byDesc[metric.Desc]; !exists {
+			descs = append(descs, metric.Desc)
+		}
+		byDesc[metric.Desc] = append(byDesc[metric.Desc], metric)
+	}
+	sort.SliceStable(descs, func(i, j int) bool { return descs[i].Name < descs[j].Name })
+
+	for _, desc := range descs {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", desc.Name, escapeHelp(desc.Help), desc.Name); err != nil {
+			return err
+		}
+		for _, metric := range byDesc[desc] {
+			var labels []string
+			for i, name := range desc.Labels {
+				if i < len(metric.LabelValues) {
+					labels = append(labels, fmt.Sprintf("%s=\"%s\"", name, escapeLabelValue(metric.LabelValues[i])))
+				}
+			}
+			value := strconv.FormatFloat(metric.Value, 'g', -1, 64)
+			if _, err := fmt.Fprintf(w, "%s{%s} %s\n", desc.Name, strings.Join(labels, ","), value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,146 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	nftconfig "github.com/networkplumbing/go-nft/nft/config"
+	"github.com/networkplumbing/go-nft/nft/metrics"
+)
+
+const countersConfigJSON = `{"nftables":[
+	{"metainfo":{"version":"1.0.9","release_name":"Old Doc Yak #3","json_schema_version":1}},
+	{"table":{"family":"inet","name":"filter"}},
+	{"chain":{"family":"inet","table":"filter","name":"input"}},
+	{"counter":{"family":"inet","table":"filter","name":"drops","handle":2,"packets":7,"bytes":700}},
+	{"rule":{"family":"inet","table":"filter","chain":"input","handle":4,"comment":"ssh \"admin\"","expr":[
+		{"match":{"op":"==","left":{"payload":{"protocol":"tcp","field":"dport"}},"right":22}},
+		{"counter":{"packets":10,"bytes":1000}},
+		{"accept":null}
+	]}},
+	{"rule":{"family":"inet","table":"filter","chain":"input","handle":5,"expr":[
+		{"counter":"drops"},
+		{"drop":null}
+	]}},
+	{"rule":{"family":"inet","table":"filter","chain":"input","handle":6,"expr":[{"accept":null}]}}
+]}`
+
+func TestExtractCounters(t *testing.T) {
+	config := nftconfig.New()
+	assert.NoError(t, config.FromJSON([]byte(countersConfigJSON)))
+
+	counters := metrics.ExtractCounters(config)
+	assert.Equal(t, []metrics.RuleCounter{{
+		Family: "inet", Table: "filter", Chain: "input", Comment: `ssh "admin"`, Handle: 4, Packets: 10, Bytes: 1000,
+	}}, counters.Rules)
+	assert.Equal(t, []metrics.NamedCounter{{
+		Family: "inet", Table: "filter", Name: "drops", Packets: 7, Bytes: 700,
+	}}, counters.Named)
+}
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Collect the counters metrics", func(t *testing.T) {
+		collector := metrics.NewCollector(0, readJSON(countersConfigJSON))
+		assert.NoError(t, collector.Update(ctx))
+
+		ch := make(chan metrics.Metric, 10)
+		collector.Collect(ch)
+		close(ch)
+		var collected []metrics.Metric
+		for metric := range ch {
+			collected = append(collected, metric)
+		}
+		assert.Len(t, collected, 4)
+		assert.Equal(t, metrics.RulePacketsDesc, collected[0].Desc)
+		assert.Equal(t, []string{"inet", "filter", "input", `ssh "admin"`, "4"}, collected[0].LabelValues)
+		assert.Equal(t, float64(10), collected[0].Value)
+
+		descCh := make(chan *metrics.Desc, 10)
+		collector.Describe(descCh)
+		close(descCh)
+		assert.Len(t, descCh, 4)
+	})
+
+	t.Run("Write the text exposition format", func(t *testing.T) {
+		collector := metrics.NewCollector(0, readJSON(countersConfigJSON))
+		assert.NoError(t, collector.Update(ctx))
+
+		var buf bytes.Buffer
+		assert.NoError(t, collector.WriteText(&buf))
+		expected := `# HELP nftables_counter_bytes_total Bytes counted by the named counters.
+# TYPE nftables_counter_bytes_total counter
+nftables_counter_bytes_total{family="inet",table="filter",name="drops"} 700
+# HELP nftables_counter_packets_total Packets counted by the named counters.
+# TYPE nftables_counter_packets_total counter
+nftables_counter_packets_total{family="inet",table="filter",name="drops"} 7
+# HELP nftables_rule_bytes_total Bytes counted by the rule counters.
+# TYPE nftables_rule_bytes_total counter
+nftables_rule_bytes_total{family="inet",table="filter",chain="input",comment="ssh \"admin\"",handle="4"} 1000
+# HELP nftables_rule_packets_total Packets counted by the rule counters.
+# TYPE nftables_rule_packets_total counter
+nftables_rule_packets_total{family="inet",table="filter",chain="input",comment="ssh \"admin\"",handle="4"} 10
+`
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("A failed read keeps the previous counters", func(t *testing.T) {
+		failRead := false
+		collector := metrics.NewCollector(0, func(ctx context.Context) (*nftconfig.Config, error) {
+			if failRead {
+				return nil, fmt.Errorf("read failure")
+			}
+			return readJSON(countersConfigJSON)(ctx)
+		})
+		assert.NoError(t, collector.Update(ctx))
+
+		failRead = true
+		assert.Error(t, collector.Update(ctx))
+		counters, err := collector.Counters()
+		assert.Error(t, err)
+		assert.Len(t, counters.Rules, 1)
+	})
+
+	t.Run("Run with a non-positive interval uses the default interval", func(t *testing.T) {
+		collector := metrics.NewCollector(-time.Second, readJSON(countersConfigJSON))
+		runCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		collector.Run(runCtx)
+
+		counters, err := collector.Counters()
+		assert.NoError(t, err)
+		assert.Len(t, counters.Rules, 1)
+	})
+}
+
+func readJSON(data string) metrics.ReadFunc {
+	return func(context.Context) (*nftconfig.Config, error) {
+		config := nftconfig.New()
+		return config, config.FromJSON([]byte(data))
+	}
+}
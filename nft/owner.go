@@ -0,0 +1,92 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package nft
+
+import (
+	"context"
+
+	nftconfig "github.com/networkplumbing/go-nft/nft/config"
+)
+
+type Owner = nftconfig.Owner
+
+// NewCommentOwner returns an owner which marks its chains and rules through their comment.
+func NewCommentOwner(name string) *Owner {
+	return nftconfig.NewCommentOwner(name)
+}
+
+// NewTablePrefixOwner returns an owner of the tables which name starts with the given prefix.
+func NewTablePrefixOwner(prefix string) *Owner {
+	return nftconfig.NewTablePrefixOwner(prefix)
+}
+
+// ReadOwnedConfig loads the nftables configuration from the system and
+// returns only the objects owned by the given owner.
+// The filter commands limit the loaded configuration, in the same manner as in ReadConfig.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ReadOwnedConfig(ctx context.Context, owner *Owner, filterCommands ...string) (*Config, error) {
+	return defaultClient.ReadOwnedConfig(ctx, owner, filterCommands...)
+}
+
+// ReadOwnedConfig loads the objects owned by the given owner from the system.
+func (c *Client) ReadOwnedConfig(ctx context.Context, owner *Owner, filterCommands ...string) (*Config, error) {
+	config, err := c.ReadConfig(ctx, filterCommands...)
+	if err != nil {
+		return nil, err
+	}
+	return owner.Owned(config), nil
+}
+
+// DeleteOwned removes from the system all the objects owned by the given owner.
+// The filter commands limit the scope of the removal, in the same manner as in ReadConfig.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func DeleteOwned(ctx context.Context, owner *Owner, filterCommands ...string) error {
+	return defaultClient.DeleteOwned(ctx, owner, filterCommands...)
+}
+
+// DeleteOwned removes from the system all the objects owned by the given owner.
+func (c *Client) DeleteOwned(ctx context.Context, owner *Owner, filterCommands ...string) error {
+	config, err := c.ReadConfig(ctx, filterCommands...)
+	if err != nil {
+		return err
+	}
+	return c.ApplyConfig(ctx, owner.DeleteOwned(config))
+}
+
+// ReplaceOwned removes from the system all the objects owned by the given owner and
+// applies the desired configuration instead, in a single transaction.
+// The filter commands limit the scope of the removal, in the same manner as in ReadConfig.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ReplaceOwned(ctx context.Context, owner *Owner, desired *Config, filterCommands ...string) error {
+	return defaultClient.ReplaceOwned(ctx, owner, desired, filterCommands...)
+}
+
+// ReplaceOwned replaces the objects owned by the given owner with the desired configuration.
+func (c *Client) ReplaceOwned(ctx context.Context, owner *Owner, desired *Config, filterCommands ...string) error {
+	config, err := c.ReadConfig(ctx, filterCommands...)
+	if err != nil {
+		return err
+	}
+	replaceConfig, err := owner.ReplaceOwned(config, desired)
+	if err != nil {
+		return err
+	}
+	return c.ApplyConfig(ctx, replaceConfig)
+}
@@ -0,0 +1,234 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package nft
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+const (
+	cmdTable     = "table"
+	cmdTables    = "tables"
+	cmdChain     = "chain"
+	cmdChains    = "chains"
+	cmdSet       = "set"
+	cmdMap       = "map"
+	cmdFlowtable = "flowtable"
+)
+
+// ReadTable loads the table from the system, with all its content (e.g. chains, rules and sets).
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ReadTable(ctx context.Context, family AddressFamily, name string) (*Config, error) {
+	return defaultClient.ReadTable(ctx, family, name)
+}
+
+// ReadTable loads the table from the system, with all its content.
+func (c *Client) ReadTable(ctx context.Context, family AddressFamily, name string) (*Config, error) {
+	args, err := objectArgs(cmdTable, family, name)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadConfig(ctx, args...)
+}
+
+// ReadChain loads the chain and its rules from the system.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ReadChain(ctx context.Context, family AddressFamily, table, name string) (*schema.Chain, []*schema.Rule, error) {
+	return defaultClient.ReadChain(ctx, family, table, name)
+}
+
+// ReadChain loads the chain and its rules from the system.
+func (c *Client) ReadChain(ctx context.Context, family AddressFamily, table, name string) (*schema.Chain, []*schema.Rule, error) {
+	args, err := objectArgs(cmdChain, family, table, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	config, err := c.ReadConfig(ctx, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var chain *schema.Chain
+	var rules []*schema.Rule
+	for _, nftable := range config.Nftables {
+		if nftable.Chain != nil {
+			chain = nftable.Chain
+		}
+		if nftable.Rule != nil {
+			rules = append(rules, nftable.Rule)
+		}
+	}
+	if chain == nil {
+		return nil, nil, fmt.Errorf("chain %s %s %s not found in the listing", family, table, name)
+	}
+	return chain, rules, nil
+}
+
+// ReadSet loads the set, with its elements, from the system.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ReadSet(ctx context.Context, family AddressFamily, table, name string) (*schema.Set, error) {
+	return defaultClient.ReadSet(ctx, family, table, name)
+}
+
+// ReadSet loads the set, with its elements, from the system.
+func (c *Client) ReadSet(ctx context.Context, family AddressFamily, table, name string) (*schema.Set, error) {
+	config, err := c.readObject(ctx, cmdSet, family, table, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, nftable := range config.Nftables {
+		if nftable.Set != nil {
+			return nftable.Set, nil
+		}
+	}
+	return nil, fmt.Errorf("set %s %s %s not found in the listing", family, table, name)
+}
+
+// ReadMap loads the map, with its elements, from the system.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ReadMap(ctx context.Context, family AddressFamily, table, name string) (*schema.Map, error) {
+	return defaultClient.ReadMap(ctx, family, table, name)
+}
+
+// ReadMap loads the map, with its elements, from the system.
+func (c *Client) ReadMap(ctx context.Context, family AddressFamily, table, name string) (*schema.Map, error) {
+	config, err := c.readObject(ctx, cmdMap, family, table, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, nftable := range config.Nftables {
+		if nftable.Map != nil {
+			return nftable.Map, nil
+		}
+	}
+	return nil, fmt.Errorf("map %s %s %s not found in the listing", family, table, name)
+}
+
+// ReadFlowtable loads the flowtable from the system.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ReadFlowtable(ctx context.Context, family AddressFamily, table, name string) (*schema.Flowtable, error) {
+	return defaultClient.ReadFlowtable(ctx, family, table, name)
+}
+
+// ReadFlowtable loads the flowtable from the system.
+func (c *Client) ReadFlowtable(ctx context.Context, family AddressFamily, table, name string) (*schema.Flowtable, error) {
+	config, err := c.readObject(ctx, cmdFlowtable, family, table, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, nftable := range config.Nftables {
+		if nftable.Flowtable != nil {
+			return nftable.Flowtable, nil
+		}
+	}
+	return nil, fmt.Errorf("flowtable %s %s %s not found in the listing", family, table, name)
+}
+
+// ListTables loads the tables defined on the system, without their content.
+// An empty family lists the tables of all the families.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ListTables(ctx context.Context, family AddressFamily) ([]*schema.Table, error) {
+	return defaultClient.ListTables(ctx, family)
+}
+
+// ListTables loads the tables defined on the system, without their content.
+func (c *Client) ListTables(ctx context.Context, family AddressFamily) ([]*schema.Table, error) {
+	config, err := c.listObjects(ctx, cmdTables, family)
+	if err != nil {
+		return nil, err
+	}
+	var tables []*schema.Table
+	for _, nftable := range config.Nftables {
+		if nftable.Table != nil {
+			tables = append(tables, nftable.Table)
+		}
+	}
+	return tables, nil
+}
+
+// ListChains loads the chains defined on the system, without their rules.
+// An empty family lists the chains of all the families.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ListChains(ctx context.Context, family AddressFamily) ([]*schema.Chain, error) {
+	return defaultClient.ListChains(ctx, family)
+}
+
+// ListChains loads the chains defined on the system, without their rules.
+func (c *Client) ListChains(ctx context.Context, family AddressFamily) ([]*schema.Chain, error) {
+	config, err := c.listObjects(ctx, cmdChains, family)
+	if err != nil {
+		return nil, err
+	}
+	var chains []*schema.Chain
+	for _, nftable := range config.Nftables {
+		if nftable.Chain != nil {
+			chains = append(chains, nftable.Chain)
+		}
+	}
+	return chains, nil
+}
+
+func (c *Client) readObject(ctx context.Context, object string, family AddressFamily, table, name string) (*Config, error) {
+	args, err := objectArgs(object, family, table, name)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadConfig(ctx, args...)
+}
+
+func (c *Client) listObjects(ctx context.Context, objects string, family AddressFamily) (*Config, error) {
+	args := []string{objects}
+	if family != "" {
+		if err := validateFamily(family); err != nil {
+			return nil, err
+		}
+		args = append(args, string(family))
+	}
+	return c.ReadConfig(ctx, args...)
+}
+
+// objectArgs builds the list command arguments of an object.
+// The names are quoted, so names which are not plain nft identifiers (e.g. with spaces) are accepted
+// and cannot be interpreted as other syntax elements.
+func objectArgs(object string, family AddressFamily, names ...string) ([]string, error) {
+	if err := validateFamily(family); err != nil {
+		return nil, err
+	}
+	args := []string{object, string(family)}
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, "\"\r\n\x00") {
+			return nil, fmt.Errorf("invalid object name %q", name)
+		}
+		args = append(args, `"`+name+`"`)
+	}
+	return args, nil
+}
+
+func validateFamily(family AddressFamily) error {
+	switch family {
+	case FamilyIP, FamilyIP6, FamilyINET, FamilyARP, FamilyBridge, FamilyNETDEV:
+		return nil
+	}
+	return fmt.Errorf("invalid address family %q", family)
+}
@@ -0,0 +1,208 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package nft
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+const (
+	cmdCounter  = "counter"
+	cmdCounters = "counters"
+	cmdRules    = "rules"
+	cmdQuota    = "quota"
+	cmdQuotas   = "quotas"
+	cmdReset    = "reset"
+	// cmdSeparator separates multiple commands sent in a single invocation.
+	cmdSeparator = ";"
+)
+
+// ResetScope selects the counters to reset:
+// - A named counter object, when Counter is set.
+// - The counters of the chain rules, when Chain is set.
+// - The counters of the table rules and the table named counter objects, otherwise.
+//
+// When resetting quotas, it selects a named quota object when Quota is set,
+// and the table named quota objects otherwise.
+type ResetScope struct {
+	Family  AddressFamily
+	Table   string
+	Chain   string
+	Counter string
+	Quota   string
+}
+
+// CounterValue is the value of a counter, as read when it was reset.
+// Rule counters have the Chain and Handle set, while named counters have the Name set.
+type CounterValue struct {
+	Family  string
+	Table   string
+	Chain   string
+	Handle  *int
+	Comment string
+	Name    string
+	Counter schema.Counter
+}
+
+// ResetFunc resets the stateful objects selected by the reset command and returns their values.
+// The reset command may hold multiple commands, separated by ";" (e.g. `counters table ip t ; reset rules table ip t`),
+// which are expected to run in a single invocation.
+type ResetFunc func(ctx context.Context, resetCommand ...string) (*Config, error)
+
+// ResetCounters resets the counters in the given scope and returns their values before the reset.
+// Resetting rule counters requires nftables 1.0.7 or newer.
+// The table scope resets the named counters and the rule counters in a single nft invocation.
+// The commands are not atomic: nft validates both before running them, but when the rule counters
+// fail to reset, the named counters may already be reset and their values are not returned.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ResetCounters(ctx context.Context, scope ResetScope) ([]CounterValue, error) {
+	return defaultClient.ResetCounters(ctx, scope)
+}
+
+// ResetCounters resets the counters in the given scope and returns their values before the reset.
+func (c *Client) ResetCounters(ctx context.Context, scope ResetScope) ([]CounterValue, error) {
+	return ResetCountersWith(ctx, scope, c.executor.Reset)
+}
+
+// ResetCountersWith resets the counters in the given scope using the given reset function,
+// allowing the reset to run through other backends (e.g. libnftables or a configured nftexec.Executor).
+func ResetCountersWith(ctx context.Context, scope ResetScope, reset ResetFunc) ([]CounterValue, error) {
+	command, err := scope.command()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := reset(ctx, command...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset counters: %v", err)
+	}
+	return CounterValues(config), nil
+}
+
+// CounterValues returns the values of the rule counters and named counters in the configuration.
+// A rule with multiple counter statements has a value per counter, references to named
+// counters are skipped.
+func CounterValues(c *Config) []CounterValue {
+	var values []CounterValue
+	for _, nftable := range c.Nftables {
+		if rule := nftable.Rule; rule != nil {
+			for _, statement := range rule.Expr {
+				if statement.Counter != nil && statement.Counter.Name == "" && statement.Counter.Ref == nil {
+					values = append(values, CounterValue{
+						Family:  rule.Family,
+						Table:   rule.Table,
+						Chain:   rule.Chain,
+						Handle:  rule.Handle,
+						Comment: rule.Comment,
+						Counter: *statement.Counter,
+					})
+				}
+			}
+		}
+		if counter := nftable.Counter; counter != nil {
+			values = append(values, CounterValue{
+				Family:  counter.Family,
+				Table:   counter.Table,
+				Name:    counter.Name,
+				Comment: counter.Comment,
+				Counter: schema.Counter{Packets: counter.Packets, Bytes: counter.Bytes},
+			})
+		}
+	}
+	return values
+}
+
+// ResetQuotas resets the named quotas in the given scope and returns their values before the reset.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func ResetQuotas(ctx context.Context, scope ResetScope) ([]schema.NamedQuota, error) {
+	return defaultClient.ResetQuotas(ctx, scope)
+}
+
+// ResetQuotas resets the named quotas in the given scope and returns their values before the reset.
+func (c *Client) ResetQuotas(ctx context.Context, scope ResetScope) ([]schema.NamedQuota, error) {
+	return ResetQuotasWith(ctx, scope, c.executor.Reset)
+}
+
+// ResetQuotasWith resets the named quotas in the given scope using the given reset function,
+// allowing the reset to run through other backends (e.g. libnftables or a configured nftexec.Executor).
+func ResetQuotasWith(ctx context.Context, scope ResetScope, reset ResetFunc) ([]schema.NamedQuota, error) {
+	command, err := scope.quotaCommand()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := reset(ctx, command...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset quotas: %v", err)
+	}
+	return QuotaValues(config), nil
+}
+
+// QuotaValues returns the values of the named quotas in the configuration.
+func QuotaValues(c *Config) []schema.NamedQuota {
+	var values []schema.NamedQuota
+	for _, nftable := range c.Nftables {
+		if quota := nftable.Quota; quota != nil {
+			values = append(values, *quota)
+		}
+	}
+	return values
+}
+
+func (s ResetScope) quotaCommand() ([]string, error) {
+	if s.Chain != "" || s.Counter != "" {
+		return nil, fmt.Errorf("quotas are selected by table or name, not by chain or counter")
+	}
+	if s.Quota != "" {
+		return objectArgs(cmdQuota, s.Family, s.Table, s.Quota)
+	}
+	args, err := objectArgs(cmdTable, s.Family, s.Table)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{cmdQuotas}, args...), nil
+}
+
+// command returns the reset command of the counters in the scope.
+// The table scope resets both the named counters and the rule counters, with two commands
+// sent in a single invocation, each following command starting with the reset keyword.
+func (s ResetScope) command() ([]string, error) {
+	switch {
+	case s.Counter != "":
+		return objectArgs(cmdCounter, s.Family, s.Table, s.Counter)
+	case s.Chain != "":
+		args, err := objectArgs(cmdChain, s.Family, s.Table, s.Chain)
+		if err != nil {
+			return nil, err
+		}
+		return append([]string{cmdRules}, args...), nil
+	default:
+		args, err := objectArgs(cmdTable, s.Family, s.Table)
+		if err != nil {
+			return nil, err
+		}
+		command := append([]string{cmdCounters}, args...)
+		command = append(command, cmdSeparator, cmdReset, cmdRules)
+		return append(command, args...), nil
+	}
+}
@@ -46,8 +46,12 @@ type Chain struct {
 	Family string `json:"family"`
 	Table  string `json:"table"`
 	Name   string `json:"name"`
+	Handle *int   `json:"handle,omitempty"`
 	Type   string `json:"type,omitempty"`
 	Hook   string `json:"hook,omitempty"`
 	Prio   *int   `json:"prio,omitempty"`
-	Policy string `json:"policy,omitempty"`
+	// Dev lists the network devices of a netdev family chain.
+	Dev     Devices `json:"dev,omitempty"`
+	Policy  string  `json:"policy,omitempty"`
+	Comment string  `json:"comment,omitempty"`
 }
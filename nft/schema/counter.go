@@ -0,0 +1,44 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+// NamedCounter is a counter object, which rules reference by its name.
+type NamedCounter struct {
+	Family  string `json:"family"`
+	Table   string `json:"table"`
+	Name    string `json:"name"`
+	Handle  *int   `json:"handle,omitempty"`
+	Packets int    `json:"packets"`
+	Bytes   int    `json:"bytes"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// NamedQuota is a quota object, which rules reference by its name.
+// The quota is exceeded once the used bytes pass the quota bytes (or, when inverted, until they do).
+type NamedQuota struct {
+	Family  string `json:"family"`
+	Table   string `json:"table"`
+	Name    string `json:"name"`
+	Handle  *int   `json:"handle,omitempty"`
+	Bytes   int    `json:"bytes"`
+	Used    int    `json:"used"`
+	Inv     bool   `json:"inv,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
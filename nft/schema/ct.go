@@ -0,0 +1,130 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+// Ct is a conntrack expression, e.g. `ct state` or `ct original saddr`.
+type Ct struct {
+	Key    string `json:"key"`
+	Family string `json:"family,omitempty"`
+	Dir    string `json:"dir,omitempty"`
+}
+
+// Ct Keys
+const (
+	CtKeyState      = "state"
+	CtKeyDirection  = "direction"
+	CtKeyStatus     = "status"
+	CtKeyMark       = "mark"
+	CtKeyExpiration = "expiration"
+	CtKeyHelper     = "helper"
+	CtKeyLabel      = "label"
+	CtKeyL3Proto    = "l3proto"
+	CtKeySAddr      = "saddr"
+	CtKeyDAddr      = "daddr"
+	CtKeyProtocol   = "protocol"
+	CtKeyProtoSrc   = "proto-src"
+	CtKeyProtoDst   = "proto-dst"
+	CtKeyZone       = "zone"
+	CtKeyBytes      = "bytes"
+	CtKeyPackets    = "packets"
+	CtKeyAvgPkt     = "avgpkt"
+	CtKeyID         = "id"
+)
+
+// Ct Directions
+const (
+	CtDirOriginal = "original"
+	CtDirReply    = "reply"
+)
+
+// Ct L3 Protocols
+const (
+	CtL3ProtoIP   = "ip"
+	CtL3ProtoIP6  = "ip6"
+	CtL3ProtoINET = "inet"
+)
+
+// Ct Protocols
+const (
+	CtProtoTCP     = "tcp"
+	CtProtoUDP     = "udp"
+	CtProtoUDPLite = "udplite"
+	CtProtoSCTP    = "sctp"
+	CtProtoDCCP    = "dccp"
+	CtProtoGRE     = "gre"
+	CtProtoICMP    = "icmp"
+	CtProtoICMPv6  = "icmpv6"
+)
+
+// CtHelper is a conntrack helper object, e.g. `ct helper ftp-standard { type "ftp" protocol tcp; }`.
+// Connections are assigned to the helper with the `ct helper set` statement.
+type CtHelper struct {
+	Family   string `json:"family"`
+	Table    string `json:"table"`
+	Name     string `json:"name"`
+	Handle   *int   `json:"handle,omitempty"`
+	Type     string `json:"type"`
+	Protocol string `json:"protocol"`
+	L3Proto  string `json:"l3proto,omitempty"`
+}
+
+// CtTimeout is a conntrack timeout policy object, which overrides the default timeouts (in seconds) per state.
+// Connections are assigned to the policy with the `ct timeout set` statement.
+type CtTimeout struct {
+	Family   string         `json:"family"`
+	Table    string         `json:"table"`
+	Name     string         `json:"name"`
+	Handle   *int           `json:"handle,omitempty"`
+	Protocol string         `json:"protocol"`
+	L3Proto  string         `json:"l3proto,omitempty"`
+	Policy   map[string]int `json:"policy,omitempty"`
+}
+
+// Ct Timeout States
+const (
+	CtTimeoutStateSynSent     = "syn_sent"
+	CtTimeoutStateSynRecv     = "syn_recv"
+	CtTimeoutStateEstablished = "established"
+	CtTimeoutStateFinWait     = "fin_wait"
+	CtTimeoutStateCloseWait   = "close_wait"
+	CtTimeoutStateLastAck     = "last_ack"
+	CtTimeoutStateTimeWait    = "time_wait"
+	CtTimeoutStateClose       = "close"
+	CtTimeoutStateSynSent2    = "syn_sent2"
+	CtTimeoutStateRetrans     = "retrans"
+	CtTimeoutStateUnack       = "unacknowledged"
+	CtTimeoutStateUnreplied   = "unreplied"
+	CtTimeoutStateReplied     = "replied"
+)
+
+// CtExpectation is a conntrack expectation object, creating expected connections to the destination port.
+// The timeout is in milliseconds.
+// Connections are assigned to the expectation with the `ct expectation set` statement.
+type CtExpectation struct {
+	Family   string `json:"family"`
+	Table    string `json:"table"`
+	Name     string `json:"name"`
+	Handle   *int   `json:"handle,omitempty"`
+	L3Proto  string `json:"l3proto,omitempty"`
+	Protocol string `json:"protocol"`
+	Dport    int    `json:"dport"`
+	Timeout  int    `json:"timeout"`
+	Size     int    `json:"size"`
+}
@@ -0,0 +1,190 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// Prefix is an address prefix, e.g. `10.0.0.0/8`.
+type Prefix struct {
+	Addr string `json:"addr"`
+	Len  int    `json:"len"`
+}
+
+// Range is an inclusive range of values, e.g. `1000-2000`.
+type Range struct {
+	Min Expression
+	Max Expression
+}
+
+func (r Range) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]Expression{r.Min, r.Max})
+}
+
+func (r *Range) UnmarshalJSON(data []byte) error {
+	var bounds []Expression
+	if err := json.Unmarshal(data, &bounds); err != nil {
+		return err
+	}
+	if len(bounds) != 2 {
+		return fmt.Errorf("range requires two values, got %d", len(bounds))
+	}
+	r.Min, r.Max = bounds[0], bounds[1]
+	return nil
+}
+
+// ExpressionList is a list of expressions, used by concatenations, anonymous sets and set elements.
+// A single expression is accepted in place of a list when decoding.
+// A list entry which is a two entries array is decoded as a map pair.
+type ExpressionList []Expression
+
+func (l *ExpressionList) UnmarshalJSON(data []byte) error {
+	var rawList []json.RawMessage
+	if err := json.Unmarshal(data, &rawList); err == nil {
+		list := make(ExpressionList, 0, len(rawList))
+		for _, rawExpression := range rawList {
+			expression, err := unmarshalListEntry(rawExpression)
+			if err != nil {
+				return err
+			}
+			list = append(list, expression)
+		}
+		*l = list
+		return nil
+	}
+	var expression Expression
+	if err := json.Unmarshal(data, &expression); err != nil {
+		return err
+	}
+	*l = ExpressionList{expression}
+	return nil
+}
+
+func unmarshalListEntry(data json.RawMessage) (Expression, error) {
+	var pair []Expression
+	if err := json.Unmarshal(data, &pair); err == nil && len(pair) == 2 {
+		return NewMapPair(pair[0], pair[1]), nil
+	}
+	var expression Expression
+	err := json.Unmarshal(data, &expression)
+	return expression, err
+}
+
+// BinaryOp is a binary operation on two expressions, e.g. `tcp flags & (syn | ack)`.
+// The operator is one of OperAND, OperOR, OperXOR, OperLSH and OperRSH.
+type BinaryOp struct {
+	Op    string
+	Left  Expression
+	Right Expression
+}
+
+// NewBinaryOp returns a binary operation expression, the operands may be binary operations themselves.
+func NewBinaryOp(op string, left, right Expression) Expression {
+	return Expression{BinaryOp: &BinaryOp{Op: op, Left: left, Right: right}}
+}
+
+func isBinaryOperator(op string) bool {
+	switch op {
+	case OperAND, OperOR, OperXOR, OperLSH, OperRSH:
+		return true
+	}
+	return false
+}
+
+// unmarshalBinaryOp decodes a binary operation, returning nil when the data is not one.
+func unmarshalBinaryOp(data []byte) (*BinaryOp, error) {
+	var dynamicStructure map[string]json.RawMessage
+	if err := json.Unmarshal(data, &dynamicStructure); err != nil {
+		return nil, err
+	}
+	if len(dynamicStructure) != 1 {
+		return nil, nil
+	}
+	for op, operandsData := range dynamicStructure {
+		if !isBinaryOperator(op) {
+			return nil, nil
+		}
+		var operands []Expression
+		if err := json.Unmarshal(operandsData, &operands); err != nil {
+			return nil, err
+		}
+		if len(operands) != 2 {
+			return nil, fmt.Errorf("binary operation %q requires two operands, got %d", op, len(operands))
+		}
+		return &BinaryOp{Op: op, Left: operands[0], Right: operands[1]}, nil
+	}
+	return nil, nil
+}
+
+// NewPrefix returns a prefix expression of the IP network.
+func NewPrefix(ipNet *net.IPNet) Expression {
+	length, _ := ipNet.Mask.Size()
+	return Expression{Prefix: &Prefix{Addr: ipNet.IP.String(), Len: length}}
+}
+
+// NewRange returns a range expression between the min and max values (inclusive).
+func NewRange(min, max Expression) Expression {
+	return Expression{Range: &Range{Min: min, Max: max}}
+}
+
+// NewPortRange returns a range expression between the min and max ports (inclusive),
+// or a number expression for a single port when min equals max.
+func NewPortRange(min, max uint16) Expression {
+	if min == max {
+		return NewNumber(float64(min))
+	}
+	return NewRange(NewNumber(float64(min)), NewNumber(float64(max)))
+}
+
+// NewConcat returns a concatenation of the expressions, e.g. `ip saddr . tcp dport`.
+func NewConcat(expressions ...Expression) Expression {
+	return Expression{Concat: expressions}
+}
+
+// NewSet returns an anonymous set of the expressions, e.g. `{ 22, 80, 443 }`.
+func NewSet(expressions ...Expression) Expression {
+	return Expression{Set: expressions}
+}
+
+// NewString returns a string expression.
+func NewString(s string) Expression {
+	return Expression{String: &s}
+}
+
+// NewNumber returns a numeric expression.
+func NewNumber(n float64) Expression {
+	return Expression{Float64: &n}
+}
+
+// NewBool returns a boolean expression, e.g. to check the existence of an extension header.
+func NewBool(b bool) Expression {
+	return Expression{Bool: &b}
+}
+
+// isTyped reports whether the expression holds a value of the typed fields (i.e. not RowData).
+func (e Expression) isTyped() bool {
+	return e.String != nil || e.Float64 != nil || e.Bool != nil || e.Payload != nil || e.Meta != nil || e.Ct != nil ||
+		e.Prefix != nil || e.Range != nil || e.Concat != nil || e.Set != nil || e.BinaryOp != nil ||
+		e.Exthdr != nil || e.TcpOption != nil || e.SctpChunk != nil ||
+		e.Numgen != nil || e.Jhash != nil || e.Symhash != nil || e.Map != nil || e.MapPair != nil || e.Elem != nil
+}
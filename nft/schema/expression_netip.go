@@ -0,0 +1,30 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+import "net/netip"
+
+// NewPrefixFromNetip returns a prefix expression of the network prefix.
+func NewPrefixFromNetip(prefix netip.Prefix) Expression {
+	return Expression{Prefix: &Prefix{Addr: prefix.Masked().Addr().String(), Len: prefix.Bits()}}
+}
@@ -0,0 +1,124 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+// Exthdr is an IPv6 extension header field, e.g. `exthdr frag more-fragments`.
+// Without a field, the expression matched with a boolean checks the header existence:
+//
+//	Match{Op: OperEQ, Left: Expression{Exthdr: &Exthdr{Name: ExthdrFrag}}, Right: NewBool(true)}
+type Exthdr struct {
+	Name   string `json:"name"`
+	Field  string `json:"field,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+// TcpOption is a TCP option field, e.g. `tcp option maxseg size`.
+// Without a field, the expression matched with a boolean checks the option existence.
+type TcpOption struct {
+	Name  string `json:"name"`
+	Field string `json:"field,omitempty"`
+}
+
+// SctpChunk is an SCTP chunk field, e.g. `sctp chunk data tsn`.
+// Without a field, the expression matched with a boolean checks the chunk existence.
+type SctpChunk struct {
+	Name  string `json:"name"`
+	Field string `json:"field,omitempty"`
+}
+
+// IPv6 Extension Headers and Fields
+const (
+	ExthdrHopByHop    = "hbh"
+	ExthdrRouting     = "rt"
+	ExthdrRouting0    = "rt0"
+	ExthdrRouting2    = "rt2"
+	ExthdrSegRouting  = "srh"
+	ExthdrFrag        = "frag"
+	ExthdrDestination = "dst"
+	ExthdrMobility    = "mh"
+
+	ExthdrFieldNextHdr       = "nexthdr"
+	ExthdrFieldHdrLength     = "hdrlength"
+	ExthdrFieldType          = "type"
+	ExthdrFieldSegLeft       = "seg-left"
+	ExthdrFieldReserved      = "reserved"
+	ExthdrFieldReserved2     = "reserved2"
+	ExthdrFieldFragOff       = "frag-off"
+	ExthdrFieldMoreFragments = "more-fragments"
+	ExthdrFieldId            = "id"
+	ExthdrFieldChecksum      = "checksum"
+	ExthdrFieldAddr          = "addr"
+	ExthdrFieldLastEntry     = "last-entry"
+	ExthdrFieldFlags         = "flags"
+	ExthdrFieldTag           = "tag"
+	ExthdrFieldSid           = "sid"
+)
+
+// TCP Options and Fields
+const (
+	TcpOptionEOL       = "eol"
+	TcpOptionNOP       = "nop"
+	TcpOptionMaxSeg    = "maxseg"
+	TcpOptionWindow    = "window"
+	TcpOptionSackPerm  = "sack-perm"
+	TcpOptionSack      = "sack"
+	TcpOptionTimestamp = "timestamp"
+	TcpOptionMPTCP     = "mptcp"
+
+	TcpOptionFieldKind    = "kind"
+	TcpOptionFieldLength  = "length"
+	TcpOptionFieldSize    = "size"
+	TcpOptionFieldCount   = "count"
+	TcpOptionFieldLeft    = "left"
+	TcpOptionFieldRight   = "right"
+	TcpOptionFieldTSVal   = "tsval"
+	TcpOptionFieldTSEcr   = "tsecr"
+	TcpOptionFieldSubtype = "subtype"
+)
+
+// SCTP Chunks and Fields
+const (
+	SctpChunkData             = "data"
+	SctpChunkInit             = "init"
+	SctpChunkInitAck          = "init-ack"
+	SctpChunkSack             = "sack"
+	SctpChunkHeartbeat        = "heartbeat"
+	SctpChunkHeartbeatAck     = "heartbeat-ack"
+	SctpChunkAbort            = "abort"
+	SctpChunkShutdown         = "shutdown"
+	SctpChunkShutdownAck      = "shutdown-ack"
+	SctpChunkError            = "error"
+	SctpChunkCookieEcho       = "cookie-echo"
+	SctpChunkCookieAck        = "cookie-ack"
+	SctpChunkECNE             = "ecne"
+	SctpChunkCWR              = "cwr"
+	SctpChunkShutdownComplete = "shutdown-complete"
+	SctpChunkAsconfAck        = "asconf-ack"
+	SctpChunkForwardTSN       = "forward-tsn"
+	SctpChunkAsconf           = "asconf"
+
+	SctpChunkFieldType   = "type"
+	SctpChunkFieldFlags  = "flags"
+	SctpChunkFieldLength = "length"
+	SctpChunkFieldTSN    = "tsn"
+	SctpChunkFieldStream = "stream"
+	SctpChunkFieldSSN    = "ssn"
+	SctpChunkFieldPPID   = "ppid"
+)
@@ -0,0 +1,55 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+// Tproxy redirects the packet to a local socket without changing its header, e.g. `tproxy ip to 127.0.0.1:9000`.
+// The family is required in inet tables when an address is specified.
+type Tproxy struct {
+	Family string      `json:"family,omitempty"`
+	Addr   *Expression `json:"addr,omitempty"`
+	Port   *Expression `json:"port,omitempty"`
+}
+
+// Queue passes the packet to userspace, e.g. `queue num 3-5 fanout,bypass`.
+// The queue number is a number or a range expression.
+type Queue struct {
+	Num   *Expression `json:"num,omitempty"`
+	Flags *Flags      `json:"flags,omitempty"`
+}
+
+// Queue Flags
+const (
+	QueueFlagBypass = "bypass"
+	QueueFlagFanout = "fanout"
+)
+
+// Dup duplicates the packet to the address, optionally through the device, e.g. `dup to 10.0.0.1 device "eth0"`.
+type Dup struct {
+	Addr Expression  `json:"addr"`
+	Dev  *Expression `json:"dev,omitempty"`
+}
+
+// Fwd forwards the packet out of the device, optionally to the address of the family (netdev tables only),
+// e.g. `fwd ip to 10.0.0.1 device "eth0"`.
+type Fwd struct {
+	Dev    Expression  `json:"dev"`
+	Family string      `json:"family,omitempty"`
+	Addr   *Expression `json:"addr,omitempty"`
+}
@@ -0,0 +1,129 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+import (
+	"fmt"
+	"net"
+)
+
+// Numgen Modes
+const (
+	NumgenModeInc    = "inc"
+	NumgenModeRandom = "random"
+)
+
+// Numgen generates a number between the offset and offset+mod-1,
+// incrementally or randomly, e.g. `numgen inc mod 3`.
+type Numgen struct {
+	Mode   string `json:"mode"`
+	Mod    int    `json:"mod"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+// Jhash hashes the expression (e.g. a concatenation of the source address and port)
+// to a number between the offset and offset+mod-1, e.g. `jhash ip saddr mod 2`.
+type Jhash struct {
+	Mod    int        `json:"mod"`
+	Offset int        `json:"offset,omitempty"`
+	Expr   Expression `json:"expr"`
+	Seed   *int       `json:"seed,omitempty"`
+}
+
+// Symhash hashes the packet symmetrically (the same value for both directions of a flow)
+// to a number between the offset and offset+mod-1, e.g. `symhash mod 2`.
+type Symhash struct {
+	Mod    int `json:"mod"`
+	Offset int `json:"offset,omitempty"`
+}
+
+// MapExpr looks up the key value in the map data and evaluates to the mapped value.
+// The data is either an anonymous map of key/value pairs or a reference to a named map (e.g. "@mymap").
+type MapExpr struct {
+	Key  Expression `json:"key"`
+	Data Expression `json:"data"`
+}
+
+// MapPair is an element of an anonymous map (or of a map object), mapping the key to the value.
+type MapPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// NewMapPair returns an element of an anonymous map, mapping the key to the value.
+func NewMapPair(key, value Expression) Expression {
+	return Expression{MapPair: &MapPair{Key: key, Value: value}}
+}
+
+// DnatEndpoint is a DNAT destination address, with its relative weight.
+type DnatEndpoint struct {
+	Addr   string
+	Weight int
+}
+
+// NewWeightedDnat returns a DNAT statement, for a table of the given family, which distributes
+// the connections between the endpoints by their relative weight, in round-robin (NumgenModeInc)
+// or randomly (NumgenModeRandom), e.g. `dnat to numgen inc mod 3 map { 0-1 : 10.0.0.1, 2 : 10.0.0.2 }`.
+// All the endpoints must be of the same address family. The family is set in inet tables, where it is required.
+func NewWeightedDnat(tableFamily, mode string, endpoints ...DnatEndpoint) (*Dnat, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("weighted DNAT requires at least one endpoint")
+	}
+	if mode != NumgenModeInc && mode != NumgenModeRandom {
+		return nil, fmt.Errorf("invalid weighted DNAT mode %q", mode)
+	}
+
+	var family *string
+	var elements []Expression
+	total := 0
+	for _, endpoint := range endpoints {
+		if endpoint.Weight <= 0 {
+			return nil, fmt.Errorf("invalid weight %d of endpoint %s", endpoint.Weight, endpoint.Addr)
+		}
+		ip := net.ParseIP(endpoint.Addr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid address of endpoint %s", endpoint.Addr)
+		}
+		addrFamily := FamilyIP6
+		if ip.To4() != nil {
+			addrFamily = FamilyIP
+		}
+		endpointFamily, err := natFamily(tableFamily, addrFamily, endpoint.Addr)
+		if err != nil {
+			return nil, err
+		}
+		if family != nil && *family != *endpointFamily {
+			return nil, fmt.Errorf("endpoint %s does not match the %q family of the other endpoints", endpoint.Addr, *family)
+		}
+		family = endpointFamily
+		key := NewNumber(float64(total))
+		if endpoint.Weight > 1 {
+			key = NewRange(key, NewNumber(float64(total+endpoint.Weight-1)))
+		}
+		elements = append(elements, NewMapPair(key, NewString(endpoint.Addr)))
+		total += endpoint.Weight
+	}
+
+	addr := Expression{Map: &MapExpr{
+		Key:  Expression{Numgen: &Numgen{Mode: mode, Mod: total}},
+		Data: NewSet(elements...),
+	}}
+	return &Dnat{Addr: &addr, Family: family}, nil
+}
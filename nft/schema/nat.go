@@ -0,0 +1,99 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+import "fmt"
+
+// PortRange is an inclusive range of ports, a single port when Min equals Max.
+type PortRange struct {
+	Min uint16
+	Max uint16
+}
+
+// Validate checks the SNAT statement can be used in a table of the given family.
+func (s *Snat) Validate(tableFamily string) error {
+	return validateNat("snat", tableFamily, s.Addr, s.Family, s.TypeFlags)
+}
+
+// Validate checks the DNAT statement can be used in a table of the given family.
+func (d *Dnat) Validate(tableFamily string) error {
+	return validateNat("dnat", tableFamily, d.Addr, d.Family, d.TypeFlags)
+}
+
+// Validate checks the NAT statements can be used in a table of the given family.
+func (n Nat) Validate(tableFamily string) error {
+	if n.Snat != nil {
+		if err := n.Snat.Validate(tableFamily); err != nil {
+			return err
+		}
+	}
+	if n.Dnat != nil {
+		if err := n.Dnat.Validate(tableFamily); err != nil {
+			return err
+		}
+	}
+	if n.Masquerade != nil && !isNatTableFamily(tableFamily) {
+		return fmt.Errorf("masquerade is not supported in %q tables", tableFamily)
+	}
+	if n.Redirect != nil && !isNatTableFamily(tableFamily) {
+		return fmt.Errorf("redirect is not supported in %q tables", tableFamily)
+	}
+	return nil
+}
+
+func validateNat(kind, tableFamily string, addr *Expression, family *string, typeFlags *Flags) error {
+	if !isNatTableFamily(tableFamily) {
+		return fmt.Errorf("%s is not supported in %q tables", kind, tableFamily)
+	}
+	if family != nil {
+		if *family != FamilyIP && *family != FamilyIP6 {
+			return fmt.Errorf("%s family must be %q or %q: %q", kind, FamilyIP, FamilyIP6, *family)
+		}
+		if tableFamily != FamilyINET && *family != tableFamily {
+			return fmt.Errorf("%s family %q does not match the %q table family", kind, *family, tableFamily)
+		}
+	} else if tableFamily == FamilyINET && addr != nil {
+		return fmt.Errorf("%s to an address requires a family in %q tables", kind, FamilyINET)
+	}
+	if typeFlags != nil && len(typeFlags.Flags) > 0 && addr == nil {
+		return fmt.Errorf("%s type flags require an address", kind)
+	}
+	return nil
+}
+
+// natFamily returns the NAT family for an address of the given family, in a table of the given family.
+// The family is set in inet tables, where it is required, and omitted otherwise.
+func natFamily(tableFamily, addrFamily, addr string) (*string, error) {
+	switch tableFamily {
+	case FamilyINET:
+		return &addrFamily, nil
+	case FamilyIP, FamilyIP6:
+		if tableFamily != addrFamily {
+			return nil, fmt.Errorf("NAT address %s does not match the %q table family", addr, tableFamily)
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("NAT is not supported in %q tables", tableFamily)
+	}
+}
+
+func isNatTableFamily(tableFamily string) bool {
+	return tableFamily == FamilyIP || tableFamily == FamilyIP6 || tableFamily == FamilyINET
+}
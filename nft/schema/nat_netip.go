@@ -0,0 +1,101 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// NewSnatFromNetip returns a SNAT statement to the address and optional ports, for a table of the given family.
+// The family is set in inet tables, where it is required.
+func NewSnatFromNetip(tableFamily string, addr netip.Addr, ports *PortRange) (*Snat, error) {
+	family, err := natFamilyOf(tableFamily, addr)
+	if err != nil {
+		return nil, err
+	}
+	address := NewString(addr.Unmap().String())
+	return &Snat{Addr: &address, Family: family, Port: natPorts(ports)}, nil
+}
+
+// NewSnatFromNetipPrefix returns a SNAT statement to the addresses of the prefix and optional ports,
+// for a table of the given family.
+func NewSnatFromNetipPrefix(tableFamily string, prefix netip.Prefix, ports *PortRange) (*Snat, error) {
+	family, err := natFamilyOf(tableFamily, prefix.Addr())
+	if err != nil {
+		return nil, err
+	}
+	address := NewPrefixFromNetip(prefix)
+	return &Snat{
+		Addr:      &address,
+		Family:    family,
+		Port:      natPorts(ports),
+		TypeFlags: &Flags{Flags: []string{NATTypeFlagPrefix}},
+	}, nil
+}
+
+// NewDnatFromNetip returns a DNAT statement to the address and optional ports, for a table of the given family.
+// The family is set in inet tables, where it is required.
+func NewDnatFromNetip(tableFamily string, addr netip.Addr, ports *PortRange) (*Dnat, error) {
+	family, err := natFamilyOf(tableFamily, addr)
+	if err != nil {
+		return nil, err
+	}
+	address := NewString(addr.Unmap().String())
+	return &Dnat{Addr: &address, Family: family, Port: natPorts(ports)}, nil
+}
+
+// NewDnatFromNetipPrefix returns a DNAT statement to the addresses of the prefix and optional ports,
+// for a table of the given family.
+func NewDnatFromNetipPrefix(tableFamily string, prefix netip.Prefix, ports *PortRange) (*Dnat, error) {
+	family, err := natFamilyOf(tableFamily, prefix.Addr())
+	if err != nil {
+		return nil, err
+	}
+	address := NewPrefixFromNetip(prefix)
+	return &Dnat{
+		Addr:      &address,
+		Family:    family,
+		Port:      natPorts(ports),
+		TypeFlags: &Flags{Flags: []string{NATTypeFlagPrefix}},
+	}, nil
+}
+
+func natFamilyOf(tableFamily string, addr netip.Addr) (*string, error) {
+	if !addr.IsValid() {
+		return nil, fmt.Errorf("invalid NAT address")
+	}
+	addrFamily := FamilyIP6
+	if addr.Unmap().Is4() {
+		addrFamily = FamilyIP
+	}
+	return natFamily(tableFamily, addrFamily, addr.String())
+}
+
+func natPorts(ports *PortRange) *Expression {
+	if ports == nil {
+		return nil
+	}
+	port := NewPortRange(ports.Min, ports.Max)
+	return &port
+}
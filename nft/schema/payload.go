@@ -0,0 +1,248 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Payload Bases, used by the raw payload form.
+const (
+	PayloadBaseLL = "ll" // Link layer header.
+	PayloadBaseNH = "nh" // Network header.
+	PayloadBaseTH = "th" // Transport header.
+)
+
+// Payload Protocols and Fields
+const (
+	// TCP
+	PayloadProtocolTCP      = "tcp"
+	PayloadFieldTCPSPort    = "sport"
+	PayloadFieldTCPDPort    = "dport"
+	PayloadFieldTCPSequence = "sequence"
+	PayloadFieldTCPAckSeq   = "ackseq"
+	PayloadFieldTCPDoff     = "doff"
+	PayloadFieldTCPReserved = "reserved"
+	PayloadFieldTCPFlags    = "flags"
+	PayloadFieldTCPWindow   = "window"
+	PayloadFieldTCPChecksum = "checksum"
+	PayloadFieldTCPUrgPtr   = "urgptr"
+
+	// UDP
+	PayloadProtocolUDP      = "udp"
+	PayloadFieldUDPSPort    = "sport"
+	PayloadFieldUDPDPort    = "dport"
+	PayloadFieldUDPLength   = "length"
+	PayloadFieldUDPChecksum = "checksum"
+
+	// UDP-Lite
+	PayloadProtocolUDPLite      = "udplite"
+	PayloadFieldUDPLiteSPort    = "sport"
+	PayloadFieldUDPLiteDPort    = "dport"
+	PayloadFieldUDPLiteCsumCov  = "csumcov"
+	PayloadFieldUDPLiteChecksum = "checksum"
+
+	// SCTP
+	PayloadProtocolSCTP      = "sctp"
+	PayloadFieldSCTPSPort    = "sport"
+	PayloadFieldSCTPDPort    = "dport"
+	PayloadFieldSCTPVTag     = "vtag"
+	PayloadFieldSCTPChecksum = "checksum"
+
+	// DCCP
+	PayloadProtocolDCCP   = "dccp"
+	PayloadFieldDCCPSPort = "sport"
+	PayloadFieldDCCPDPort = "dport"
+	PayloadFieldDCCPType  = "type"
+
+	// ICMP
+	PayloadProtocolICMP      = "icmp"
+	PayloadFieldICMPType     = "type"
+	PayloadFieldICMPCode     = "code"
+	PayloadFieldICMPChecksum = "checksum"
+	PayloadFieldICMPId       = "id"
+	PayloadFieldICMPSequence = "sequence"
+	PayloadFieldICMPGateway  = "gateway"
+	PayloadFieldICMPMtu      = "mtu"
+
+	// ICMPv6
+	PayloadProtocolICMPv6          = "icmpv6"
+	PayloadFieldICMPv6Type         = "type"
+	PayloadFieldICMPv6Code         = "code"
+	PayloadFieldICMPv6Checksum     = "checksum"
+	PayloadFieldICMPv6ParamProblem = "parameter-problem"
+	PayloadFieldICMPv6PacketTooBig = "packet-too-big"
+	PayloadFieldICMPv6Id           = "id"
+	PayloadFieldICMPv6Sequence     = "sequence"
+	PayloadFieldICMPv6MaxDelay     = "max-delay"
+
+	// ARP
+	PayloadProtocolARP        = "arp"
+	PayloadFieldARPHType      = "htype"
+	PayloadFieldARPPType      = "ptype"
+	PayloadFieldARPHLen       = "hlen"
+	PayloadFieldARPPLen       = "plen"
+	PayloadFieldARPOperation  = "operation"
+	PayloadFieldARPSAddrEther = "saddr ether"
+	PayloadFieldARPSAddrIP    = "saddr ip"
+	PayloadFieldARPDAddrEther = "daddr ether"
+	PayloadFieldARPDAddrIP    = "daddr ip"
+
+	// VLAN
+	PayloadProtocolVLAN  = "vlan"
+	PayloadFieldVLANId   = "id"
+	PayloadFieldVLANDei  = "dei"
+	PayloadFieldVLANCfi  = "cfi"
+	PayloadFieldVLANPcp  = "pcp"
+	PayloadFieldVLANType = "type"
+
+	// GRE
+	PayloadProtocolGRE      = "gre"
+	PayloadFieldGREVersion  = "version"
+	PayloadFieldGREFlags    = "flags"
+	PayloadFieldGREProtocol = "protocol"
+
+	// ESP
+	PayloadProtocolESP      = "esp"
+	PayloadFieldESPSpi      = "spi"
+	PayloadFieldESPSequence = "sequence"
+
+	// AH
+	PayloadProtocolAH       = "ah"
+	PayloadFieldAHNextHdr   = "nexthdr"
+	PayloadFieldAHHdrLength = "hdrlength"
+	PayloadFieldAHReserved  = "reserved"
+	PayloadFieldAHSpi       = "spi"
+	PayloadFieldAHSequence  = "sequence"
+
+	// IPComp
+	PayloadProtocolComp     = "comp"
+	PayloadFieldCompNextHdr = "nexthdr"
+	PayloadFieldCompFlags   = "flags"
+	PayloadFieldCompCpi     = "cpi"
+
+	// Transport header, matching the ports of any transport protocol.
+	PayloadProtocolTH   = "th"
+	PayloadFieldTHSPort = "sport"
+	PayloadFieldTHDPort = "dport"
+)
+
+// payloadFields lists the known fields per payload protocol.
+var payloadFields = map[string][]string{
+	PayloadProtocolEther: {PayloadFieldEtherDAddr, PayloadFieldEtherSAddr, PayloadFieldEtherType},
+	PayloadProtocolIP4: {
+		PayloadFieldIPVer, PayloadFieldIP4HdrLen, PayloadFieldIPDscp, PayloadFieldIPEcn, PayloadFieldIPLen,
+		PayloadFieldIP4Id, PayloadFieldIP4FragOff, PayloadFieldIP4Ttl, PayloadFieldIP4Protocol,
+		PayloadFieldIP4Chksum, PayloadFieldIPSAddr, PayloadFieldIPDAddr,
+	},
+	PayloadProtocolIP6: {
+		PayloadFieldIPVer, PayloadFieldIPDscp, PayloadFieldIPEcn, PayloadFieldIP6FlowLabel, PayloadFieldIPLen,
+		PayloadFieldIP6NextHdr, PayloadFieldIP6HopLimit, PayloadFieldIPSAddr, PayloadFieldIPDAddr,
+	},
+	PayloadProtocolTCP: {
+		PayloadFieldTCPSPort, PayloadFieldTCPDPort, PayloadFieldTCPSequence, PayloadFieldTCPAckSeq,
+		PayloadFieldTCPDoff, PayloadFieldTCPReserved, PayloadFieldTCPFlags, PayloadFieldTCPWindow,
+		PayloadFieldTCPChecksum, PayloadFieldTCPUrgPtr,
+	},
+	PayloadProtocolUDP: {
+		PayloadFieldUDPSPort, PayloadFieldUDPDPort, PayloadFieldUDPLength, PayloadFieldUDPChecksum,
+	},
+	PayloadProtocolUDPLite: {
+		PayloadFieldUDPLiteSPort, PayloadFieldUDPLiteDPort, PayloadFieldUDPLiteCsumCov, PayloadFieldUDPLiteChecksum,
+	},
+	PayloadProtocolSCTP: {
+		PayloadFieldSCTPSPort, PayloadFieldSCTPDPort, PayloadFieldSCTPVTag, PayloadFieldSCTPChecksum,
+	},
+	PayloadProtocolDCCP: {PayloadFieldDCCPSPort, PayloadFieldDCCPDPort, PayloadFieldDCCPType},
+	PayloadProtocolICMP: {
+		PayloadFieldICMPType, PayloadFieldICMPCode, PayloadFieldICMPChecksum, PayloadFieldICMPId,
+		PayloadFieldICMPSequence, PayloadFieldICMPGateway, PayloadFieldICMPMtu,
+	},
+	PayloadProtocolICMPv6: {
+		PayloadFieldICMPv6Type, PayloadFieldICMPv6Code, PayloadFieldICMPv6Checksum, PayloadFieldICMPv6ParamProblem,
+		PayloadFieldICMPv6PacketTooBig, PayloadFieldICMPv6Id, PayloadFieldICMPv6Sequence, PayloadFieldICMPv6MaxDelay,
+	},
+	PayloadProtocolARP: {
+		PayloadFieldARPHType, PayloadFieldARPPType, PayloadFieldARPHLen, PayloadFieldARPPLen, PayloadFieldARPOperation,
+		PayloadFieldARPSAddrEther, PayloadFieldARPSAddrIP, PayloadFieldARPDAddrEther, PayloadFieldARPDAddrIP,
+	},
+	PayloadProtocolVLAN: {
+		PayloadFieldVLANId, PayloadFieldVLANDei, PayloadFieldVLANCfi, PayloadFieldVLANPcp, PayloadFieldVLANType,
+	},
+	PayloadProtocolGRE: {PayloadFieldGREVersion, PayloadFieldGREFlags, PayloadFieldGREProtocol},
+	PayloadProtocolESP: {PayloadFieldESPSpi, PayloadFieldESPSequence},
+	PayloadProtocolAH: {
+		PayloadFieldAHNextHdr, PayloadFieldAHHdrLength, PayloadFieldAHReserved, PayloadFieldAHSpi, PayloadFieldAHSequence,
+	},
+	PayloadProtocolComp: {PayloadFieldCompNextHdr, PayloadFieldCompFlags, PayloadFieldCompCpi},
+	PayloadProtocolTH:   {PayloadFieldTHSPort, PayloadFieldTHDPort},
+}
+
+// NewRawPayload returns a payload expression of the given bits length, at the bits offset from the base header.
+func NewRawPayload(base string, offset, length int) *Payload {
+	return &Payload{Base: base, Offset: offset, Len: length}
+}
+
+// IsRaw reports whether the payload is in the raw form (base, offset and length).
+func (p Payload) IsRaw() bool {
+	return p.Base != ""
+}
+
+// Validate checks the payload protocol field is known, or in the raw form, that the base is known
+// and the offset and length are valid.
+func (p Payload) Validate() error {
+	if p.IsRaw() {
+		switch p.Base {
+		case PayloadBaseLL, PayloadBaseNH, PayloadBaseTH:
+		default:
+			return fmt.Errorf("unknown payload base %q", p.Base)
+		}
+		if p.Offset < 0 || p.Len <= 0 {
+			return fmt.Errorf("invalid raw payload offset %d and length %d", p.Offset, p.Len)
+		}
+		return nil
+	}
+
+	fields, knownProtocol := payloadFields[p.Protocol]
+	if !knownProtocol {
+		return fmt.Errorf("unknown payload protocol %q", p.Protocol)
+	}
+	for _, field := range fields {
+		if field == p.Field {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown payload field %q of protocol %q", p.Field, p.Protocol)
+}
+
+func (p Payload) MarshalJSON() ([]byte, error) {
+	if p.IsRaw() {
+		return json.Marshal(struct {
+			Base   string `json:"base"`
+			Offset int    `json:"offset"`
+			Len    int    `json:"len"`
+		}{p.Base, p.Offset, p.Len})
+	}
+	return json.Marshal(struct {
+		Protocol string `json:"protocol"`
+		Field    string `json:"field"`
+	}{p.Protocol, p.Field})
+}
@@ -22,6 +22,7 @@ package schema
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 )
 
 type Rule struct {
@@ -35,17 +36,110 @@ type Rule struct {
 }
 
 type Statement struct {
-	Counter *Counter `json:"counter,omitempty"`
-	Match   *Match   `json:"match,omitempty"`
+	Counter *Counter      `json:"counter,omitempty"`
+	Match   *Match        `json:"match,omitempty"`
+	Vmap    *Vmap         `json:"vmap,omitempty"`
+	Mangle  *Mangle       `json:"mangle,omitempty"`
+	Tproxy  *Tproxy       `json:"tproxy,omitempty"`
+	Queue   *Queue        `json:"queue,omitempty"`
+	Dup     *Dup          `json:"dup,omitempty"`
+	Fwd     *Fwd          `json:"fwd,omitempty"`
+	Limit   *Limit        `json:"limit,omitempty"`
+	Set     *SetStatement `json:"set,omitempty"`
+	// CtHelper, CtTimeout and CtExpectation assign the connection to the named object.
+	// The object is referenced by its name (e.g. `ct helper set "ftp-standard"`)
+	// or looked up in a map (e.g. `ct helper set tcp dport map @helpers`).
+	CtHelper      *Expression `json:"ct helper,omitempty"`
+	CtTimeout     *Expression `json:"ct timeout,omitempty"`
+	CtExpectation *Expression `json:"ct expectation,omitempty"`
+	Synproxy      *Synproxy   `json:"synproxy,omitempty"`
+	// Secmark labels the packet with the secmark object, referenced by its name (i.e. `meta secmark set "name"`)
+	// or looked up in a map (e.g. `meta secmark set tcp dport map @secmapping`).
+	Secmark *Expression `json:"secmark,omitempty"`
+	Notrack bool        `json:"-"`
 	Verdict
 	Nat
+
+	// RowData accepts arbitrary data which cannot be composed from the existing schema.
+	// It is also used when decoding a statement which the schema does not model (e.g. `log` or `reject`),
+	// so that it is encoded back as is.
+	RowData json.RawMessage `json:"-"`
 }
 
+const notrack = "notrack"
+
 type Counter struct {
 	Packets int `json:"packets"`
 	Bytes   int `json:"bytes"`
+	// Name references a named counter object, in which case the packets and bytes are not used.
+	Name string `json:"-"`
+	// Ref references a named counter object looked up by an expression (e.g. `counter name tcp dport map @cnt`),
+	// in which case the packets and bytes are not used.
+	Ref *Expression `json:"-"`
+}
+
+func (c Counter) MarshalJSON() ([]byte, error) {
+	if c.Name != "" {
+		return json.Marshal(c.Name)
+	}
+	if c.Ref != nil {
+		return json.Marshal(c.Ref)
+	}
+	type _Counter Counter
+	return json.Marshal(_Counter(c))
 }
 
+func (c *Counter) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*c = Counter{Name: name}
+		return nil
+	}
+
+	dynamicStructure := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &dynamicStructure); err != nil {
+		return err
+	}
+	for key := range dynamicStructure {
+		if key != "packets" && key != "bytes" {
+			ref := Expression{}
+			if err := json.Unmarshal(data, &ref); err != nil {
+				return err
+			}
+			*c = Counter{Ref: &ref}
+			return nil
+		}
+	}
+
+	type _Counter Counter
+	counter := _Counter{}
+	if err := json.Unmarshal(data, &counter); err != nil {
+		return err
+	}
+	*c = Counter(counter)
+	return nil
+}
+
+// Limit matches packets up to the rate, per time unit (e.g. `limit rate 10/second`).
+// The rate unit is "bytes" (with an optional prefix, e.g. "kbytes") when the limit is on the traffic volume.
+type Limit struct {
+	Rate      int    `json:"rate"`
+	Per       string `json:"per,omitempty"`
+	RateUnit  string `json:"rate_unit,omitempty"`
+	Burst     int    `json:"burst,omitempty"`
+	BurstUnit string `json:"burst_unit,omitempty"`
+	Inv       bool   `json:"inv,omitempty"`
+}
+
+// Limit Time Units
+const (
+	LimitPerSecond = "second"
+	LimitPerMinute = "minute"
+	LimitPerHour   = "hour"
+	LimitPerDay    = "day"
+	LimitPerWeek   = "week"
+)
+
 type Nat struct {
 	Snat       *Snat       `json:"snat,omitempty"`
 	Dnat       *Dnat       `json:"dnat,omitempty"`
@@ -54,17 +148,19 @@ type Nat struct {
 }
 
 type Snat struct {
-	Addr   *Expression `json:"addr,omitempty"`
-	Family *string     `json:"family,omitempty"`
-	Port   *Expression `json:"port,omitempty"`
-	Flags  *Flags      `json:"flags,omitempty"`
+	Addr      *Expression `json:"addr,omitempty"`
+	Family    *string     `json:"family,omitempty"`
+	Port      *Expression `json:"port,omitempty"`
+	Flags     *Flags      `json:"flags,omitempty"`
+	TypeFlags *Flags      `json:"type_flags,omitempty"`
 }
 
 type Dnat struct {
-	Addr   *Expression `json:"addr,omitempty"`
-	Family *string     `json:"family,omitempty"`
-	Port   *Expression `json:"port,omitempty"`
-	Flags  *Flags      `json:"flags,omitempty"`
+	Addr      *Expression `json:"addr,omitempty"`
+	Family    *string     `json:"family,omitempty"`
+	Port      *Expression `json:"port,omitempty"`
+	Flags     *Flags      `json:"flags,omitempty"`
+	TypeFlags *Flags      `json:"type_flags,omitempty"`
 }
 
 const masquerade = "masquerade"
@@ -92,6 +188,14 @@ const (
 	NATFlagRandom      = "random"
 	NATFlagFullyRandom = "fully-random"
 	NATFlagPersistent  = "persistent"
+	NATFlagNetmap      = "netmap"
+)
+
+// NAT Type Flags
+const (
+	NATTypeFlagInterval = "interval"
+	NATTypeFlagPrefix   = "prefix"
+	NATTypeFlagConcat   = "concat"
 )
 
 type Verdict struct {
@@ -111,6 +215,20 @@ type ToTarget struct {
 	Target string `json:"target"`
 }
 
+// Vmap applies the verdict which is mapped to the key value.
+// The data is either an anonymous map of key/verdict pairs or a reference to a named map (e.g. "@mymap").
+type Vmap struct {
+	Key  Expression `json:"key"`
+	Data Expression `json:"data"`
+}
+
+// Mangle sets the key to the value, e.g. `meta mark set 0x1` or `ip dscp set cs1`.
+// The key is a payload, meta (e.g. mark, priority, nftrace), ct (e.g. mark, label) or exthdr expression.
+type Mangle struct {
+	Key   Expression `json:"key"`
+	Value Expression `json:"value"`
+}
+
 type Match struct {
 	Op    string     `json:"op"`
 	Left  Expression `json:"left"`
@@ -118,10 +236,32 @@ type Match struct {
 }
 
 type Expression struct {
-	String  *string  `json:"-"`
-	Bool    *bool    `json:"-"`
-	Float64 *float64 `json:"-"`
-	Payload *Payload `json:"payload,omitempty"`
+	String  *string        `json:"-"`
+	Bool    *bool          `json:"-"`
+	Float64 *float64       `json:"-"`
+	Payload *Payload       `json:"payload,omitempty"`
+	Meta    *Meta          `json:"meta,omitempty"`
+	Ct      *Ct            `json:"ct,omitempty"`
+	Elem    *Elem          `json:"elem,omitempty"`
+	Prefix  *Prefix        `json:"prefix,omitempty"`
+	Range   *Range         `json:"range,omitempty"`
+	Concat  ExpressionList `json:"concat,omitempty"`
+	Set     ExpressionList `json:"set,omitempty"`
+	// BinaryOp is encoded by its operator, e.g. `{"&":[left, right]}`.
+	BinaryOp *BinaryOp `json:"-"`
+
+	Exthdr    *Exthdr    `json:"exthdr,omitempty"`
+	TcpOption *TcpOption `json:"tcp option,omitempty"`
+	SctpChunk *SctpChunk `json:"sctp chunk,omitempty"`
+
+	Numgen  *Numgen  `json:"numgen,omitempty"`
+	Jhash   *Jhash   `json:"jhash,omitempty"`
+	Symhash *Symhash `json:"symhash,omitempty"`
+	Map     *MapExpr `json:"map,omitempty"`
+	// MapPair is encoded as a two entries array, e.g. `[80, "10.0.0.1"]`.
+	// It is decoded only as an entry of an expression list (e.g. an anonymous set).
+	MapPair *MapPair `json:"-"`
+
 	// RowData accepts arbitrary data which cannot be composed from the existing schema.
 	// Use `json.RawMessage()` or `[]byte()` for the value.
 	// Example:
@@ -129,9 +269,18 @@ type Expression struct {
 	RowData json.RawMessage `json:"-"`
 }
 
+// Payload references a packet header field, by its protocol and field name, or in the
+// raw form, by its base header, bits offset and bits length.
 type Payload struct {
-	Protocol string `json:"protocol"`
-	Field    string `json:"field"`
+	Protocol string `json:"protocol,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Base     string `json:"base,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+	Len      int    `json:"len,omitempty"`
+}
+
+type Meta struct {
+	Key string `json:"key"`
 }
 
 // Verdict Operations
@@ -191,7 +340,41 @@ const (
 	PayloadFieldIP6HopLimit  = "hoplimit"
 )
 
+// Meta Expressions
+const (
+	MetaKey          = "meta"
+	MetaKeyLength    = "length"
+	MetaKeyProtocol  = "protocol"
+	MetaKeyPriority  = "priority"
+	MetaKeyRandom    = "random"
+	MetaKeyMark      = "mark"
+	MetaKeyIif       = "iif"
+	MetaKeyIifName   = "iifname"
+	MetaKeyIifType   = "iiftype"
+	MetaKeyOif       = "oif"
+	MetaKeyOifName   = "oifname"
+	MetaKeyOifType   = "oiftype"
+	MetaKeySkUID     = "skuid"
+	MetaKeySkGID     = "skgid"
+	MetaKeyNfTrace   = "nftrace"
+	MetaKeyRtClassID = "rtclassid"
+	MetaKeyIbrName   = "ibrname"
+	MetaKeyObrName   = "obrname"
+	MetaKeyPktType   = "pkttype"
+	MetaKeyCPU       = "cpu"
+	MetaKeyIifGroup  = "iifgroup"
+	MetaKeyOifGroup  = "oifgroup"
+	MetaKeyCgroup    = "cgroup"
+	MetaKeyNfProto   = "nfproto"
+	MetaKeyL4Proto   = "l4proto"
+	MetaKeySecPath   = "secpath"
+)
+
 func (s Statement) MarshalJSON() ([]byte, error) {
+	if s.RowData != nil {
+		return s.RowData, nil
+	}
+
 	type _Statement Statement
 	statement := _Statement(s)
 
@@ -218,6 +401,11 @@ func (s Statement) MarshalJSON() ([]byte, error) {
 		dynamicStructure[masquerade] = nil
 	case s.Redirect != nil && s.Redirect.Enabled && s.Redirect.Port == nil && s.Redirect.Flags == nil:
 		dynamicStructure[redirect] = nil
+	case s.Synproxy != nil && s.Synproxy.Enabled && s.Synproxy.Name == "" &&
+		s.Synproxy.Mss == nil && s.Synproxy.Wscale == nil && s.Synproxy.Flags == nil:
+		dynamicStructure[synproxy] = nil
+	case s.Notrack:
+		dynamicStructure[notrack] = nil
 	}
 
 	data, err = json.Marshal(dynamicStructure)
@@ -253,6 +441,28 @@ func (s *Statement) UnmarshalJSON(data []byte) error {
 		s.Redirect = &Redirect{Enabled: true}
 	}
 
+	if _, synproxyDefined := dynamicStructure[synproxy]; s.Synproxy == nil && synproxyDefined {
+		s.Synproxy = &Synproxy{Enabled: true}
+	}
+
+	_, s.Notrack = dynamicStructure[notrack]
+
+	// Keep the statement as is when it holds data which the schema does not model.
+	modeled, err := s.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	var original, decoded interface{}
+	if err := json.Unmarshal(data, &original); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(modeled, &decoded); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(original, decoded) {
+		*s = Statement{RowData: append(json.RawMessage(nil), data...)}
+	}
+
 	return nil
 }
 
@@ -268,6 +478,10 @@ func (e Expression) MarshalJSON() ([]byte, error) {
 		dynamicStruct = *e.Float64
 	case e.Bool != nil:
 		dynamicStruct = *e.Bool
+	case e.BinaryOp != nil:
+		dynamicStruct = map[string][]Expression{e.BinaryOp.Op: {e.BinaryOp.Left, e.BinaryOp.Right}}
+	case e.MapPair != nil:
+		dynamicStruct = []Expression{e.MapPair.Key, e.MapPair.Value}
 	default:
 		type _Expression Expression
 		dynamicStruct = _Expression(e)
@@ -301,11 +515,17 @@ func (e *Expression) UnmarshalJSON(data []byte) error {
 			return err
 		}
 		*e = Expression(expression)
+
+		binaryOp, err := unmarshalBinaryOp(data)
+		if err != nil {
+			return err
+		}
+		e.BinaryOp = binaryOp
 	default:
 		return fmt.Errorf("unsupported field type in expression: %T(%v)", dynamicStruct, dynamicStruct)
 	}
 
-	if e.String == nil && e.Float64 == nil && e.Bool == nil && e.Payload == nil {
+	if !e.isTyped() {
 		e.RowData = data
 	}
 
@@ -30,10 +30,20 @@ type Root struct {
 const ruleSetKey = "ruleset"
 
 type Objects struct {
-	Table   *Table `json:"table,omitempty"`
-	Chain   *Chain `json:"chain,omitempty"`
-	Rule    *Rule  `json:"rule,omitempty"`
-	Ruleset bool   `json:"-"`
+	Table         *Table         `json:"table,omitempty"`
+	Chain         *Chain         `json:"chain,omitempty"`
+	Rule          *Rule          `json:"rule,omitempty"`
+	Set           *Set           `json:"set,omitempty"`
+	Map           *Map           `json:"map,omitempty"`
+	Flowtable     *Flowtable     `json:"flowtable,omitempty"`
+	Counter       *NamedCounter  `json:"counter,omitempty"`
+	Quota         *NamedQuota    `json:"quota,omitempty"`
+	CtHelper      *CtHelper      `json:"ct helper,omitempty"`
+	CtTimeout     *CtTimeout     `json:"ct timeout,omitempty"`
+	CtExpectation *CtExpectation `json:"ct expectation,omitempty"`
+	Synproxy      *NamedSynproxy `json:"synproxy,omitempty"`
+	Secmark       *Secmark       `json:"secmark,omitempty"`
+	Ruleset       bool           `json:"-"`
 }
 
 func (o Objects) MarshalJSON() ([]byte, error) {
@@ -61,11 +71,40 @@ func (o Objects) MarshalJSON() ([]byte, error) {
 	return data, nil
 }
 
+func (o *Objects) UnmarshalJSON(data []byte) error {
+	type _Objects Objects
+	objects := _Objects{}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return err
+	}
+	*o = Objects(objects)
+
+	var dynamicStructure map[string]json.RawMessage
+	if err := json.Unmarshal(data, &dynamicStructure); err != nil {
+		return err
+	}
+	_, o.Ruleset = dynamicStructure[ruleSetKey]
+	return nil
+}
+
 type Nftable struct {
 	Table *Table `json:"table,omitempty"`
 	Chain *Chain `json:"chain,omitempty"`
 	Rule  *Rule  `json:"rule,omitempty"`
 
+	Set       *Set          `json:"set,omitempty"`
+	Map       *Map          `json:"map,omitempty"`
+	Flowtable *Flowtable    `json:"flowtable,omitempty"`
+	Counter   *NamedCounter `json:"counter,omitempty"`
+	Quota     *NamedQuota   `json:"quota,omitempty"`
+
+	CtHelper      *CtHelper      `json:"ct helper,omitempty"`
+	CtTimeout     *CtTimeout     `json:"ct timeout,omitempty"`
+	CtExpectation *CtExpectation `json:"ct expectation,omitempty"`
+
+	Synproxy *NamedSynproxy `json:"synproxy,omitempty"`
+	Secmark  *Secmark       `json:"secmark,omitempty"`
+
 	Add    *Objects `json:"add,omitempty"`
 	Delete *Objects `json:"delete,omitempty"`
 	Flush  *Objects `json:"flush,omitempty"`
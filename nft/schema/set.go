@@ -0,0 +1,144 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+import (
+	"encoding/json"
+)
+
+// Set Flags
+const (
+	SetFlagConstant = "constant"
+	SetFlagInterval = "interval"
+	SetFlagTimeout  = "timeout"
+	SetFlagDynamic  = "dynamic"
+)
+
+// Set Policies
+const (
+	SetPolicyPerformance = "performance"
+	SetPolicyMemory      = "memory"
+)
+
+type Set struct {
+	Family     string         `json:"family"`
+	Table      string         `json:"table"`
+	Name       string         `json:"name"`
+	Handle     *int           `json:"handle,omitempty"`
+	Type       SetType        `json:"type"`
+	Policy     string         `json:"policy,omitempty"`
+	Flags      []string       `json:"flags,omitempty"`
+	Elem       ExpressionList `json:"elem,omitempty"`
+	Timeout    *int           `json:"timeout,omitempty"`
+	GcInterval *int           `json:"gc-interval,omitempty"`
+	Size       *int           `json:"size,omitempty"`
+	Comment    string         `json:"comment,omitempty"`
+	// Stmt holds the statements attached to each element (e.g. a counter).
+	Stmt      []Statement `json:"stmt,omitempty"`
+	AutoMerge bool        `json:"auto-merge,omitempty"`
+}
+
+// Map is a set which maps its elements (keys) to values of the Map type.
+type Map struct {
+	Set
+	Map SetType `json:"map"`
+}
+
+// SetType is the data type of the set elements (e.g. "ipv4_addr").
+// A concatenated type has multiple entries (e.g. "ipv4_addr", "inet_service").
+type SetType []string
+
+func (t SetType) MarshalJSON() ([]byte, error) {
+	return marshalStringOrList(t)
+}
+
+func (t *SetType) UnmarshalJSON(data []byte) error {
+	return unmarshalStringOrList(data, (*[]string)(t))
+}
+
+// Set Statement Operations
+const (
+	SetOpAdd    = "add"
+	SetOpUpdate = "update"
+	SetOpDelete = "delete"
+)
+
+// SetStatement adds, updates or deletes the element in the referenced set from the packet path,
+// e.g. `add @seen { ip saddr timeout 10s }`.
+// The statements (e.g. limit, counter) are attached to the element.
+type SetStatement struct {
+	Op   string      `json:"op"`
+	Elem Expression  `json:"elem"`
+	Set  string      `json:"set"`
+	Stmt []Statement `json:"stmt,omitempty"`
+}
+
+// NewSetReference returns the reference to a named set (e.g. "@seen").
+func NewSetReference(name string) string {
+	return "@" + name
+}
+
+// Elem is a set element expression, with its value and properties.
+// The timeout and expires values are in seconds.
+type Elem struct {
+	Val     Expression `json:"val"`
+	Timeout int        `json:"timeout,omitempty"`
+	Expires int        `json:"expires,omitempty"`
+	Comment string     `json:"comment,omitempty"`
+	Counter *Counter   `json:"counter,omitempty"`
+}
+
+type Flowtable struct {
+	Family string  `json:"family"`
+	Table  string  `json:"table"`
+	Name   string  `json:"name"`
+	Handle *int    `json:"handle,omitempty"`
+	Hook   string  `json:"hook,omitempty"`
+	Prio   *int    `json:"prio,omitempty"`
+	Dev    Devices `json:"dev,omitempty"`
+}
+
+// Devices lists the network devices of a flowtable.
+type Devices []string
+
+func (d Devices) MarshalJSON() ([]byte, error) {
+	return marshalStringOrList(d)
+}
+
+func (d *Devices) UnmarshalJSON(data []byte) error {
+	return unmarshalStringOrList(data, (*[]string)(d))
+}
+
+// marshalStringOrList encodes a single entry list as a string and other lists as an array.
+func marshalStringOrList(list []string) ([]byte, error) {
+	if len(list) == 1 {
+		return json.Marshal(list[0])
+	}
+	return json.Marshal(list)
+}
+
+func unmarshalStringOrList(data []byte, list *[]string) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*list = []string{s}
+		return nil
+	}
+	return json.Unmarshal(data, list)
+}
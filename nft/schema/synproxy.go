@@ -0,0 +1,77 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package schema
+
+import "encoding/json"
+
+const synproxy = "synproxy"
+
+// Synproxy Flags
+const (
+	SynproxyFlagTimestamp = "timestamp"
+	SynproxyFlagSackPerm  = "sack-perm"
+)
+
+// Synproxy is the synproxy statement, which answers the TCP handshake on behalf of the server,
+// e.g. `synproxy mss 1460 wscale 7 timestamp sack-perm`.
+// A statement without parameters requires Enabled, the parameters are otherwise taken from the packet.
+type Synproxy struct {
+	Enabled bool   `json:"-"`
+	Mss     *int   `json:"mss,omitempty"`
+	Wscale  *int   `json:"wscale,omitempty"`
+	Flags   *Flags `json:"flags,omitempty"`
+	// Name references a named synproxy object, in which case the other parameters are not used.
+	Name string `json:"-"`
+}
+
+func (s Synproxy) MarshalJSON() ([]byte, error) {
+	if s.Name != "" {
+		return json.Marshal(s.Name)
+	}
+	type _Synproxy Synproxy
+	return json.Marshal(_Synproxy(s))
+}
+
+func (s *Synproxy) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*s = Synproxy{Name: name}
+		return nil
+	}
+	type _Synproxy Synproxy
+	synproxy := _Synproxy{}
+	if err := json.Unmarshal(data, &synproxy); err != nil {
+		return err
+	}
+	*s = Synproxy(synproxy)
+	return nil
+}
+
+// NamedSynproxy is a synproxy object, which rules reference by its name.
+type NamedSynproxy struct {
+	Family  string `json:"family"`
+	Table   string `json:"table"`
+	Name    string `json:"name"`
+	Handle  *int   `json:"handle,omitempty"`
+	Mss     int    `json:"mss"`
+	Wscale  int    `json:"wscale"`
+	Flags   *Flags `json:"flags,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
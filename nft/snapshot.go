@@ -0,0 +1,237 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package nft
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// RulesetSnapshot is a serializable copy of the nftables configuration,
+// as read from the system at a specific time.
+type RulesetSnapshot struct {
+	// Version is the nftables userspace version which produced the snapshot.
+	Version           string    `json:"version"`
+	JsonSchemaVersion int       `json:"json_schema_version"`
+	Timestamp         time.Time `json:"timestamp"`
+	// Filter holds the filter commands used to read the configuration (empty for the whole ruleset).
+	Filter []string `json:"filter,omitempty"`
+	Config *Config  `json:"config"`
+}
+
+type RestoreScope string
+
+// Restore Scopes
+const (
+	// RestoreRuleset flushes the whole ruleset before restoring the snapshot.
+	// It requires a snapshot of the whole ruleset, i.e. taken without filter commands.
+	RestoreRuleset RestoreScope = "ruleset"
+	// RestoreTables deletes the tables included in the snapshot before restoring them.
+	RestoreTables RestoreScope = "tables"
+	// RestoreChains flushes the chains included in the snapshot before restoring their rules.
+	RestoreChains RestoreScope = "chains"
+)
+
+// ToJSON returns the JSON encoding of the snapshot.
+func (s *RulesetSnapshot) ToJSON() ([]byte, error) {
+	return json.Marshal(*s)
+}
+
+// FromJSON decodes the provided JSON-encoded data and populates the snapshot.
+func (s *RulesetSnapshot) FromJSON(data []byte) error {
+	if err := json.Unmarshal(data, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Snapshot reads the nftables configuration from the system and returns it as a snapshot.
+// The filter commands limit the snapshot content, in the same manner as in ReadConfig.
+// Only the objects supported by the schema package (e.g. tables, chains, rules, sets, maps and stateful objects)
+// are included.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func Snapshot(ctx context.Context, filterCommands ...string) (*RulesetSnapshot, error) {
+	return defaultClient.Snapshot(ctx, filterCommands...)
+}
+
+// Snapshot reads the nftables configuration from the system and returns it as a snapshot.
+func (c *Client) Snapshot(ctx context.Context, filterCommands ...string) (*RulesetSnapshot, error) {
+	config, err := c.ReadConfig(ctx, filterCommands...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot: %v", err)
+	}
+
+	snapshot := &RulesetSnapshot{
+		Timestamp: time.Now().UTC(),
+		Filter:    filterCommands,
+		Config:    NewConfig(),
+	}
+	for _, nftable := range config.Nftables {
+		if nftable.Metainfo != nil {
+			snapshot.Version = nftable.Metainfo.Version
+			snapshot.JsonSchemaVersion = nftable.Metainfo.JsonSchemaVersion
+			continue
+		}
+		snapshot.Config.Nftables = append(snapshot.Config.Nftables, nftable)
+	}
+
+	return snapshot, nil
+}
+
+// Restore applies the snapshot on the system.
+// The scope defines which existing configuration is removed before the snapshot objects are added.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func Restore(ctx context.Context, snapshot *RulesetSnapshot, scope RestoreScope) error {
+	return defaultClient.Restore(ctx, snapshot, scope)
+}
+
+// Restore applies the snapshot on the system.
+func (c *Client) Restore(ctx context.Context, snapshot *RulesetSnapshot, scope RestoreScope) error {
+	config, err := RestoreConfig(snapshot, scope)
+	if err != nil {
+		return err
+	}
+	if err := c.ApplyConfig(ctx, config); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %v", err)
+	}
+	return nil
+}
+
+// RestoreConfig returns the configuration which Restore applies on the system.
+// It starts with the flush/delete commands matching the scope, followed by the snapshot
+// objects, without their handles, to be added.
+func RestoreConfig(snapshot *RulesetSnapshot, scope RestoreScope) (*Config, error) {
+	c := NewConfig()
+	switch scope {
+	case RestoreRuleset:
+		if len(snapshot.Filter) > 0 {
+			return nil, fmt.Errorf("restore scope %q requires a snapshot of the whole ruleset, not filtered by %q",
+				scope, strings.Join(snapshot.Filter, " "))
+		}
+		c.FlushRuleset()
+	case RestoreTables:
+		for _, nftable := range snapshot.Config.Nftables {
+			if table := nftable.Table; table != nil {
+				// Adding the table first allows the deletion to succeed when it does not exist.
+				c.AddTable(table)
+				c.DeleteTable(table)
+			}
+		}
+	case RestoreChains:
+		for _, nftable := range snapshot.Config.Nftables {
+			if chain := nftable.Chain; chain != nil {
+				c.AddTable(&schema.Table{Family: chain.Family, Name: chain.Table})
+				c.AddChain(chain)
+				c.FlushChain(chain)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported restore scope: %q", scope)
+	}
+
+	c.Nftables = append(c.Nftables, addOnlyNftables(snapshot.Config)...)
+	return c, nil
+}
+
+// addOnlyNftables returns the tables, chains, objects (e.g. sets, maps, flowtables and stateful objects)
+// and rules of the configuration, in that order, ready to be added on the system.
+// The chains precede the objects as verdict maps may reference them, the objects precede the rules
+// which reference them.
+// Handles and rule indexes are removed, as they are assigned by the system when the objects are added.
+func addOnlyNftables(c *Config) []schema.Nftable {
+	var tables, chains, objects, rules []schema.Nftable
+	for _, nftable := range c.Nftables {
+		switch {
+		case nftable.Table != nil:
+			table := *nftable.Table
+			table.Handle = nil
+			tables = append(tables, schema.Nftable{Table: &table})
+		case nftable.Chain != nil:
+			chain := *nftable.Chain
+			chain.Handle = nil
+			chains = append(chains, schema.Nftable{Chain: &chain})
+		case nftable.Rule != nil:
+			rule := *nftable.Rule
+			rule.Handle = nil
+			rule.Index = nil
+			rules = append(rules, schema.Nftable{Rule: &rule})
+		default:
+			if object, ok := addOnlyObject(nftable); ok {
+				objects = append(objects, object)
+			}
+		}
+	}
+
+	nftables := append(tables, chains...)
+	nftables = append(nftables, objects...)
+	return append(nftables, rules...)
+}
+
+// addOnlyObject returns a copy of the set, map, flowtable or stateful object entry, without its handle.
+func addOnlyObject(nftable schema.Nftable) (schema.Nftable, bool) {
+	switch {
+	case nftable.Set != nil:
+		set := *nftable.Set
+		set.Handle = nil
+		return schema.Nftable{Set: &set}, true
+	case nftable.Map != nil:
+		m := *nftable.Map
+		m.Handle = nil
+		return schema.Nftable{Map: &m}, true
+	case nftable.Flowtable != nil:
+		flowtable := *nftable.Flowtable
+		flowtable.Handle = nil
+		return schema.Nftable{Flowtable: &flowtable}, true
+	case nftable.Counter != nil:
+		counter := *nftable.Counter
+		counter.Handle = nil
+		return schema.Nftable{Counter: &counter}, true
+	case nftable.Quota != nil:
+		quota := *nftable.Quota
+		quota.Handle = nil
+		return schema.Nftable{Quota: &quota}, true
+	case nftable.CtHelper != nil:
+		helper := *nftable.CtHelper
+		helper.Handle = nil
+		return schema.Nftable{CtHelper: &helper}, true
+	case nftable.CtTimeout != nil:
+		timeout := *nftable.CtTimeout
+		timeout.Handle = nil
+		return schema.Nftable{CtTimeout: &timeout}, true
+	case nftable.CtExpectation != nil:
+		expectation := *nftable.CtExpectation
+		expectation.Handle = nil
+		return schema.Nftable{CtExpectation: &expectation}, true
+	case nftable.Synproxy != nil:
+		synproxy := *nftable.Synproxy
+		synproxy.Handle = nil
+		return schema.Nftable{Synproxy: &synproxy}, true
+	case nftable.Secmark != nil:
+		secmark := *nftable.Secmark
+		secmark.Handle = nil
+		return schema.Nftable{Secmark: &secmark}, true
+	}
+	return schema.Nftable{}, false
+}
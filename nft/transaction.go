@@ -0,0 +1,187 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package nft
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+// Transaction accumulates configuration changes (steps) and applies them
+// on the system in a single nftables batch.
+// The tables affected by the transaction are recorded when it is created,
+// allowing to restore them when the transaction fails or is rolled back.
+//
+// The snapshot includes only the objects supported by the schema package
+// (e.g. tables, chains, rules, sets, maps and stateful objects),
+// other objects in the affected tables are lost on restore.
+// Rule statements which the schema does not model are restored as read.
+type Transaction struct {
+	client   *Client
+	tables   []*schema.Table
+	snapshot *Config
+	steps    []transactionStep
+}
+
+type transactionStep struct {
+	name   string
+	config *Config
+}
+
+// TransactionError is returned when a transaction fails to apply.
+// It identifies the step which caused the failure.
+type TransactionError struct {
+	// Step is the index of the failing step, in the order the steps were added.
+	// It is set to -1 when the failing step could not be determined.
+	Step int
+	// Name is the name of the failing step.
+	Name string
+	Err  error
+	// RollbackErr reports a failure to restore the snapshot after the transaction failed.
+	RollbackErr error
+}
+
+func (e *TransactionError) Error() string {
+	msg := fmt.Sprintf("transaction failed: %v", e.Err)
+	if e.Step >= 0 {
+		msg = fmt.Sprintf("transaction step %d (%s) failed: %v", e.Step, e.Name, e.Err)
+	}
+	if e.RollbackErr != nil {
+		msg += fmt.Sprintf(", rollback failed: %v", e.RollbackErr)
+	}
+	return msg
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// NewTransaction returns a new transaction over the given tables.
+// The current configuration of the tables is read from the system and kept as a snapshot.
+// Tables which do not exist on the system are removed when the snapshot is restored.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func NewTransaction(ctx context.Context, tables ...*schema.Table) (*Transaction, error) {
+	return defaultClient.NewTransaction(ctx, tables...)
+}
+
+// NewTransaction returns a new transaction over the given tables, which runs through the client executor.
+func (c *Client) NewTransaction(ctx context.Context, tables ...*schema.Table) (*Transaction, error) {
+	existing, err := c.ReadConfig(ctx, "tables")
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot tables: %v", err)
+	}
+
+	t := &Transaction{client: c, snapshot: NewConfig()}
+	for _, table := range tables {
+		t.tables = append(t.tables, table)
+		if existing.LookupTable(table) == nil {
+			continue
+		}
+
+		tableConfig, err := c.ReadConfig(ctx, "table", table.Family, table.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot table %s %s: %v", table.Family, table.Name, err)
+		}
+		t.snapshot.Nftables = append(t.snapshot.Nftables, tableConfig.Nftables...)
+	}
+
+	return t, nil
+}
+
+// Add appends a configuration step to the transaction.
+// The name is used to identify the step when the transaction fails.
+// Steps are applied only when the transaction is committed.
+func (t *Transaction) Add(name string, c *Config) {
+	t.steps = append(t.steps, transactionStep{name: name, config: c})
+}
+
+// Commit applies all the accumulated steps in a single nftables batch.
+// On failure, the failing step is identified, the snapshot is restored (see Rollback)
+// and a *TransactionError is returned.
+// The accumulated steps are kept on failure, allowing to commit them again,
+// and cleared once committed, allowing to add new steps.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func (t *Transaction) Commit(ctx context.Context) error {
+	steps := t.steps
+
+	batch := NewConfig()
+	for _, step := range steps {
+		batch.Nftables = append(batch.Nftables, step.config.Nftables...)
+	}
+
+	err := t.client.ApplyConfig(ctx, batch)
+	if err == nil {
+		t.steps = nil
+		return nil
+	}
+
+	txErr := &TransactionError{Step: -1, Err: err}
+	if i := t.findFailingStep(ctx, steps); i >= 0 {
+		txErr.Step, txErr.Name = i, steps[i].name
+	}
+	txErr.RollbackErr = t.restore(ctx)
+	return txErr
+}
+
+// Rollback restores the affected tables to their snapshot.
+// Changes made to the tables since the transaction was created, by any writer, are lost.
+// Tables which did not exist when the transaction was created are deleted.
+// Rollback may be called after a successful commit to revert it.
+// The system is expected to have the `nft` executable deployed and nftables enabled in the kernel.
+func (t *Transaction) Rollback(ctx context.Context) error {
+	t.steps = nil
+	return t.restore(ctx)
+}
+
+func (t *Transaction) restore(ctx context.Context) error {
+	if err := t.client.ApplyConfig(ctx, t.restoreConfig()); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %v", err)
+	}
+	return nil
+}
+
+// restoreConfig builds a configuration which recreates the affected tables from the snapshot.
+// Tables are first added and then deleted, so the deletion succeeds whether they exist or not.
+func (t *Transaction) restoreConfig() *Config {
+	c := NewConfig()
+	for _, table := range t.tables {
+		c.AddTable(table)
+		c.DeleteTable(table)
+	}
+
+	c.Nftables = append(c.Nftables, addOnlyNftables(t.snapshot)...)
+	return c
+}
+
+// findFailingStep checks the accumulated steps one by one, on top of the preceding ones,
+// returning the index of the first step which fails the check.
+// It returns -1 when no step fails.
+func (t *Transaction) findFailingStep(ctx context.Context, steps []transactionStep) int {
+	batch := NewConfig()
+	for i, step := range steps {
+		batch.Nftables = append(batch.Nftables, step.config.Nftables...)
+		if err := t.client.executor.CheckConfig(ctx, batch); err != nil {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,53 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/nft"
+)
+
+func TestCapabilities(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	capabilities, err := nft.Capabilities(ctx)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, capabilities.Version)
+	assert.NotZero(t, capabilities.JsonSchemaVersion)
+	assert.True(t, capabilities.InetNAT, "NAT in the inet family is expected to be supported")
+
+	tablesConfig, err := nft.ReadConfigContext(ctx, "tables")
+	assert.NoError(t, err)
+	for _, nftable := range tablesConfig.Nftables {
+		if nftable.Table != nil {
+			assert.NotEqual(t, "go_nft_probe", nftable.Table.Name, "Probes are not expected to be applied")
+		}
+	}
+
+	cachedCapabilities, err := nft.Capabilities(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, capabilities, cachedCapabilities)
+}
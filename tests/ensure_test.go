@@ -0,0 +1,153 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/tests/testlib"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestEnsure(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, testEnsureRule)
+	testlib.RunTestWithFlushTable(t, testEnsureRuleReplacesStaleRule)
+	testlib.RunTestWithFlushTable(t, testEnsureRuleWithNormalizedStatements)
+	testlib.RunTestWithFlushTable(t, testEnsureAbsent)
+}
+
+func testEnsureRule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	rule := nft.NewRule(table, chain, []schema.Statement{{Counter: &schema.Counter{}}}, nil, nil, "test")
+
+	_, err := nft.EnsureRule(ctx, rule)
+	assert.Error(t, err, "Expecting a failure when the chain does not exist")
+
+	changes, err := nft.EnsureChain(ctx, chain)
+	assert.NoError(t, err)
+	assert.Len(t, changes.Nftables, 2, "Expecting the table and chain to be added")
+
+	changes, err = nft.EnsureRule(ctx, rule)
+	assert.NoError(t, err)
+	assert.Len(t, changes.Nftables, 1, "Expecting the rule to be added")
+
+	changes, err = nft.EnsureRule(ctx, rule)
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Nftables)
+
+	changes, err = nft.EnsureChain(ctx, chain)
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Nftables)
+
+	config, err := nft.ReadConfigContext(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, config.LookupRule(nft.NewRule(table, chain, nil, nil, nil, "")), 1)
+}
+
+func testEnsureRuleReplacesStaleRule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	_, err := nft.EnsureChain(ctx, chain)
+	assert.NoError(t, err)
+	staleRule := nft.NewRule(table, chain, []schema.Statement{{Verdict: schema.Accept()}}, nil, nil, "test")
+	_, err = nft.EnsureRule(ctx, staleRule)
+	assert.NoError(t, err)
+
+	rule := nft.NewRule(table, chain, []schema.Statement{{Verdict: schema.Drop()}}, nil, nil, "test")
+	changes, err := nft.EnsureRule(ctx, rule)
+	assert.NoError(t, err)
+	assert.Len(t, changes.Nftables, 2, "Expecting the stale rule to be deleted and the new one added")
+
+	config, err := nft.ReadConfigContext(ctx)
+	assert.NoError(t, err)
+	rules := config.LookupRule(nft.NewRule(table, chain, nil, nil, nil, "test"))
+	assert.Len(t, rules, 1)
+	assert.True(t, rules[0].Expr[0].Drop)
+}
+
+func testEnsureRuleWithNormalizedStatements(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	_, err := nft.EnsureChain(ctx, chain)
+	assert.NoError(t, err)
+
+	// The single port set is normalized by nft to the port.
+	rule := nft.NewRule(table, chain, []schema.Statement{{Match: &schema.Match{
+		Op: schema.OperEQ,
+		Left: schema.Expression{Payload: &schema.Payload{
+			Protocol: schema.PayloadProtocolTCP, Field: schema.PayloadFieldTCPDPort,
+		}},
+		Right: schema.NewSet(schema.NewNumber(22)),
+	}}, {Verdict: schema.Accept()}}, nil, nil, "")
+	_, err = nft.EnsureRule(ctx, rule)
+	assert.NoError(t, err)
+
+	_, liveRules, err := nft.ReadChain(ctx, nft.FamilyIP, table.Name, chain.Name)
+	assert.NoError(t, err)
+	assert.Len(t, liveRules, 1)
+	assert.NotEqual(t, rule.Expr, liveRules[0].Expr, "Expecting the statements to be normalized")
+
+	normalizedRule := *liveRules[0]
+	normalizedRule.Handle = nil
+	changes, err := nft.EnsureRule(ctx, &normalizedRule)
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Nftables, "Expecting the rule in its normalized form to be found")
+}
+
+func testEnsureAbsent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	_, err := nft.EnsureChain(ctx, chain)
+	assert.NoError(t, err)
+	rule := nft.NewRule(table, chain, []schema.Statement{{Verdict: schema.Accept()}}, nil, nil, "test")
+	_, err = nft.EnsureRule(ctx, rule)
+	assert.NoError(t, err)
+
+	changes, err := nft.EnsureAbsent(ctx, &schema.Objects{Rule: rule})
+	assert.NoError(t, err)
+	assert.Len(t, changes.Nftables, 1)
+
+	changes, err = nft.EnsureAbsent(ctx, &schema.Objects{Rule: rule, Chain: chain, Table: table})
+	assert.NoError(t, err)
+	assert.Len(t, changes.Nftables, 3, "Expecting the chain flush and delete and the table delete")
+
+	changes, err = nft.EnsureAbsent(ctx, &schema.Objects{Table: table})
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Nftables)
+}
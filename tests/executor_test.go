@@ -0,0 +1,144 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package tests
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/tests/testlib"
+
+	"github.com/networkplumbing/go-nft/nft"
+	nftexec "github.com/networkplumbing/go-nft/nft/exec"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestExecutor(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, testExecutorWithCustomPathAndArgs)
+	testlib.RunTestWithFlushTable(t, testExecutorWithCommandWrapper)
+	testlib.RunTestWithFlushTable(t, testClientWithExecutor)
+}
+
+func testExecutorWithCustomPathAndArgs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	path, err := exec.LookPath("nft")
+	assert.NoError(t, err)
+	executor := &nftexec.Executor{Path: path, GlobalArgs: []string{"--includepath", "/etc"}}
+
+	config := nft.NewConfig()
+	config.AddTable(nft.NewTable("mytable", nft.FamilyIP))
+	assert.NoError(t, executor.ApplyConfig(ctx, config))
+
+	newConfig, err := executor.ReadConfig(ctx, "table", "ip", "mytable")
+	assert.NoError(t, err)
+	assert.Len(t, newConfig.Nftables, 2, "Expecting the metainfo and the table entry")
+
+	invalidExecutor := &nftexec.Executor{Path: "/nonexistent/nft"}
+	_, err = invalidExecutor.ReadConfig(ctx)
+	assert.Error(t, err)
+}
+
+func testExecutorWithCommandWrapper(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var executedArgs []string
+	executor := &nftexec.Executor{
+		Env: []string{"PATH=/usr/sbin:/usr/bin:/sbin:/bin"},
+		Command: func(ctx context.Context, name string, args ...string) *exec.Cmd {
+			executedArgs = append([]string{name}, args...)
+			return exec.CommandContext(ctx, "env", append([]string{name}, args...)...)
+		},
+	}
+
+	config := nft.NewConfig()
+	config.AddTable(nft.NewTable("mytable", nft.FamilyIP))
+	echoConfig, err := executor.ApplyConfigEcho(ctx, config)
+	assert.NoError(t, err)
+	assert.Equal(t, config.Nftables, echoConfig.Nftables)
+	assert.Equal(t, "nft", executedArgs[0])
+}
+
+func testClientWithExecutor(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var executions int
+	client := nft.NewClient(&nftexec.Executor{
+		Command: func(ctx context.Context, name string, args ...string) *exec.Cmd {
+			executions++
+			return exec.CommandContext(ctx, name, args...)
+		},
+	})
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	_, err := client.EnsureChain(ctx, chain)
+	assert.NoError(t, err)
+	_, err = client.EnsureRule(ctx, nft.NewRule(table, chain, []schema.Statement{{Counter: &schema.Counter{}}}, nil, nil, "test"))
+	assert.NoError(t, err)
+
+	snapshot, err := client.Snapshot(ctx, "table", table.Family, table.Name)
+	assert.NoError(t, err)
+	assert.NoError(t, client.Restore(ctx, snapshot, nft.RestoreTables))
+
+	tx, err := client.NewTransaction(ctx, table)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit(ctx))
+
+	_, err = client.ResetCounters(ctx, nft.ResetScope{Family: nft.FamilyIP, Table: table.Name})
+	assert.NoError(t, err)
+
+	_, err = client.Capabilities(ctx)
+	assert.NoError(t, err)
+
+	assert.NotZero(t, executions)
+	executionsBefore := executions
+	_, err = nft.ReadConfigContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, executionsBefore, executions, "Expecting the package functions to use the default executor")
+}
+
+func TestClientWithInvalidExecutor(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := nft.NewClient(&nftexec.Executor{Path: "/nonexistent/nft"})
+	table := nft.NewTable("mytable", nft.FamilyIP)
+
+	_, err := client.Snapshot(ctx)
+	assert.Error(t, err)
+	_, err = client.NewTransaction(ctx, table)
+	assert.Error(t, err)
+	_, err = client.Capabilities(ctx)
+	assert.Error(t, err)
+	_, err = client.EnsureTable(ctx, table)
+	assert.Error(t, err)
+	_, err = client.ReadTable(ctx, nft.FamilyIP, table.Name)
+	assert.Error(t, err)
+	_, err = client.ResetCounters(ctx, nft.ResetScope{Family: nft.FamilyIP, Table: table.Name})
+	assert.Error(t, err)
+}
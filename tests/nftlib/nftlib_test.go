@@ -21,12 +21,15 @@
 package main
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	assert "github.com/stretchr/testify/require"
 
 	"github.com/networkplumbing/go-nft/nft"
 	nftlib "github.com/networkplumbing/go-nft/nft/lib"
+	"github.com/networkplumbing/go-nft/nft/schema"
 
 	"github.com/networkplumbing/go-nft/tests/testlib"
 )
@@ -52,3 +55,98 @@ func TestNftlib(t *testing.T) {
 		assert.Equal(t, config.Nftables, newConfig.Nftables)
 	})
 }
+
+func TestNftlibContext(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, func(t *testing.T) {
+		ctx, err := nftlib.NewContext(nftlib.OutputHandle)
+		assert.NoError(t, err)
+		defer ctx.Close()
+
+		config := nft.NewConfig()
+		config.AddTable(nft.NewTable("mytable", nft.FamilyIP))
+		assert.NoError(t, ctx.ApplyConfig(config))
+
+		newConfig, err := ctx.ReadConfig()
+		assert.NoError(t, err)
+		assert.Len(t, newConfig.Nftables, 2, "Expecting the metainfo and an empty table entry")
+
+		ctx.SetOutputFlags(nftlib.OutputStateless)
+		assert.Equal(t, nftlib.OutputStateless, ctx.OutputFlags())
+		newConfig, err = ctx.ReadConfig()
+		assert.NoError(t, err)
+		assert.Equal(t, config.Nftables[0], newConfig.Nftables[1])
+
+		newConfig, err = ctx.ApplyConfigEcho(config)
+		assert.NoError(t, err)
+		assert.Len(t, newConfig.Nftables, 1, "Expecting just the empty table entry")
+		assert.Equal(t, nftlib.OutputStateless, ctx.OutputFlags())
+
+		assert.NoError(t, ctx.Close())
+		_, err = ctx.ReadConfig()
+		assert.Error(t, err)
+	})
+}
+
+func TestNftlibWithContext(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		config := nft.NewConfig()
+		config.AddTable(nft.NewTable("mytable", nft.FamilyIP))
+		assert.NoError(t, nftlib.ApplyConfigContext(ctx, config))
+
+		newConfig, err := nftlib.ReadConfigContext(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, newConfig.Nftables, 2, "Expecting the metainfo and an empty table entry")
+
+		newConfig, err = nftlib.ApplyConfigEchoContext(ctx, config)
+		assert.NoError(t, err)
+		assert.Equal(t, config.Nftables, newConfig.Nftables)
+
+		canceledCtx, cancelNow := context.WithCancel(context.Background())
+		cancelNow()
+		_, err = nftlib.ReadConfigContext(canceledCtx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestNftlibResetCounters(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		table := nft.NewTable("mytable", nft.FamilyIP)
+		config := nft.NewConfig()
+		config.AddTable(table)
+		config.Nftables = append(config.Nftables, schema.Nftable{Add: &schema.Objects{Counter: &schema.NamedCounter{
+			Family: table.Family, Table: table.Name, Name: "mycounter", Packets: 5, Bytes: 500,
+		}}})
+		assert.NoError(t, nftlib.ApplyConfigContext(ctx, config))
+
+		values, err := nftlib.ResetCounters(ctx, nft.ResetScope{Family: nft.FamilyIP, Table: table.Name})
+		assert.NoError(t, err)
+		assert.Len(t, values, 1)
+		assert.Equal(t, schema.Counter{Packets: 5, Bytes: 500}, values[0].Counter)
+	})
+}
+
+func TestNftlibResetQuotas(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		table := nft.NewTable("mytable", nft.FamilyIP)
+		config := nft.NewConfig()
+		config.AddTable(table)
+		config.Nftables = append(config.Nftables, schema.Nftable{Add: &schema.Objects{Quota: &schema.NamedQuota{
+			Family: table.Family, Table: table.Name, Name: "myquota", Bytes: 1000, Used: 100,
+		}}})
+		assert.NoError(t, nftlib.ApplyConfigContext(ctx, config))
+
+		values, err := nftlib.ResetQuotas(ctx, nft.ResetScope{Family: nft.FamilyIP, Table: table.Name})
+		assert.NoError(t, err)
+		assert.Len(t, values, 1)
+		assert.Equal(t, 100, values[0].Used)
+	})
+}
@@ -0,0 +1,137 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/tests/testlib"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestReadTypedObjects(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, testReadTypedObjects)
+	testlib.RunTestWithFlushTable(t, testReadInvalidNames)
+	testlib.RunTestWithFlushTable(t, testReadQuotedNames)
+}
+
+func testReadTypedObjects(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyINET)
+	chain := nft.NewRegularChain(table, "mychain")
+	prio := 0
+	config := nft.NewConfig()
+	config.AddTable(table)
+	config.AddChain(chain)
+	config.AddRule(nft.NewRule(table, chain, []schema.Statement{{Counter: &schema.Counter{}}}, nil, nil, "test"))
+	config.Nftables = append(config.Nftables,
+		schema.Nftable{Add: &schema.Objects{Set: &schema.Set{
+			Family: table.Family, Table: table.Name, Name: "myset", Type: schema.SetType{"ipv4_addr"},
+		}}},
+		schema.Nftable{Add: &schema.Objects{Map: &schema.Map{
+			Set: schema.Set{Family: table.Family, Table: table.Name, Name: "mymap", Type: schema.SetType{"inet_service"}},
+			Map: schema.SetType{"verdict"},
+		}}},
+		schema.Nftable{Add: &schema.Objects{Flowtable: &schema.Flowtable{
+			Family: table.Family, Table: table.Name, Name: "myflowtable",
+			Hook: schema.HookIngress, Prio: &prio, Dev: schema.Devices{"lo"},
+		}}},
+	)
+	assert.NoError(t, nft.ApplyConfigContext(ctx, config))
+
+	tables, err := nft.ListTables(ctx, nft.FamilyINET)
+	assert.NoError(t, err)
+	assert.Len(t, tables, 1)
+	assert.Equal(t, table.Name, tables[0].Name)
+
+	chains, err := nft.ListChains(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, chains, 1)
+	assert.Equal(t, chain.Name, chains[0].Name)
+
+	tableConfig, err := nft.ReadTable(ctx, nft.FamilyINET, table.Name)
+	assert.NoError(t, err)
+	assert.Len(t, tableConfig.LookupRule(nft.NewRule(table, chain, nil, nil, nil, "")), 1)
+
+	readChain, rules, err := nft.ReadChain(ctx, nft.FamilyINET, table.Name, chain.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, chain.Name, readChain.Name)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, "test", rules[0].Comment)
+
+	set, err := nft.ReadSet(ctx, nft.FamilyINET, table.Name, "myset")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.SetType{"ipv4_addr"}, set.Type)
+
+	readMap, err := nft.ReadMap(ctx, nft.FamilyINET, table.Name, "mymap")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.SetType{"inet_service"}, readMap.Type)
+	assert.Equal(t, schema.SetType{"verdict"}, readMap.Map)
+
+	flowtable, err := nft.ReadFlowtable(ctx, nft.FamilyINET, table.Name, "myflowtable")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.Devices{"lo"}, flowtable.Dev)
+}
+
+func testReadQuotedNames(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// The names are not plain nft identifiers, they are accepted by nft when quoted.
+	table := nft.NewTable("my table", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "1chain")
+	config := nft.NewConfig()
+	config.AddTable(table)
+	config.AddChain(chain)
+	assert.NoError(t, nft.ApplyConfigContext(ctx, config))
+
+	chains, err := nft.ListChains(ctx, nft.FamilyIP)
+	assert.NoError(t, err)
+	assert.Len(t, chains, 1)
+
+	readChain, _, err := nft.ReadChain(ctx, nft.FamilyIP, chains[0].Table, chains[0].Name)
+	assert.NoError(t, err)
+	assert.Equal(t, chain.Name, readChain.Name)
+
+	_, err = nft.ReadTable(ctx, nft.FamilyIP, table.Name)
+	assert.NoError(t, err)
+}
+
+func testReadInvalidNames(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := nft.ReadTable(ctx, nft.FamilyIP, `mytable"; flush ruleset; "`)
+	assert.Error(t, err)
+	_, err = nft.ReadTable(ctx, nft.FamilyIP, "mytable\nflush ruleset")
+	assert.Error(t, err)
+	_, _, err = nft.ReadChain(ctx, "ipx", "mytable", "mychain")
+	assert.Error(t, err)
+	_, err = nft.ReadSet(ctx, nft.FamilyIP, "mytable", "")
+	assert.Error(t, err)
+}
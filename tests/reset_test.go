@@ -0,0 +1,185 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/tests/testlib"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestResetCounters(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, testResetChainCounters)
+	testlib.RunTestWithFlushTable(t, testResetNamedCounter)
+	testlib.RunTestWithFlushTable(t, testResetTableCounters)
+}
+
+func TestResetCountersWith(t *testing.T) {
+	var commands [][]string
+	reset := func(ctx context.Context, resetCommand ...string) (*nft.Config, error) {
+		commands = append(commands, resetCommand)
+		return nft.NewConfig(), nil
+	}
+
+	_, err := nft.ResetCountersWith(context.Background(), nft.ResetScope{Family: nft.FamilyIP, Table: "mytable"}, reset)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{
+		"counters", "table", "ip", `"mytable"`, ";", "reset", "rules", "table", "ip", `"mytable"`,
+	}}, commands, "Expecting the table counters to be reset in a single invocation")
+}
+
+func TestResetQuotas(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, testResetNamedQuotas)
+}
+
+func TestResetQuotasWith(t *testing.T) {
+	var commands [][]string
+	reset := func(ctx context.Context, resetCommand ...string) (*nft.Config, error) {
+		commands = append(commands, resetCommand)
+		config := nft.NewConfig()
+		config.Nftables = append(config.Nftables, schema.Nftable{Quota: &schema.NamedQuota{
+			Family: schema.FamilyIP, Table: "mytable", Name: "myquota", Bytes: 1000, Used: 100,
+		}})
+		return config, nil
+	}
+
+	values, err := nft.ResetQuotasWith(context.Background(), nft.ResetScope{Family: nft.FamilyIP, Table: "mytable"}, reset)
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.NamedQuota{{Family: "ip", Table: "mytable", Name: "myquota", Bytes: 1000, Used: 100}}, values)
+
+	_, err = nft.ResetQuotasWith(
+		context.Background(), nft.ResetScope{Family: nft.FamilyIP, Table: "mytable", Quota: "myquota"}, reset,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"quotas", "table", "ip", `"mytable"`},
+		{"quota", "ip", `"mytable"`, `"myquota"`},
+	}, commands)
+
+	_, err = nft.ResetQuotasWith(
+		context.Background(), nft.ResetScope{Family: nft.FamilyIP, Table: "mytable", Chain: "mychain"}, reset,
+	)
+	assert.Error(t, err)
+}
+
+func testResetChainCounters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	config := nft.NewConfig()
+	config.AddTable(table)
+	config.AddChain(chain)
+	counter := schema.Statement{Counter: &schema.Counter{Packets: 10, Bytes: 1000}}
+	config.AddRule(nft.NewRule(table, chain, []schema.Statement{counter}, nil, nil, "counted"))
+	assert.NoError(t, nft.ApplyConfigContext(ctx, config))
+
+	scope := nft.ResetScope{Family: nft.FamilyIP, Table: table.Name, Chain: chain.Name}
+	values, err := nft.ResetCounters(ctx, scope)
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, "counted", values[0].Comment)
+	assert.Equal(t, schema.Counter{Packets: 10, Bytes: 1000}, values[0].Counter)
+
+	values, err = nft.ResetCounters(ctx, scope)
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, schema.Counter{}, values[0].Counter)
+}
+
+func testResetNamedCounter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	config := nft.NewConfig()
+	config.AddTable(table)
+	config.Nftables = append(config.Nftables, schema.Nftable{Add: &schema.Objects{Counter: &schema.NamedCounter{
+		Family: table.Family, Table: table.Name, Name: "mycounter", Packets: 5, Bytes: 500,
+	}}})
+	assert.NoError(t, nft.ApplyConfigContext(ctx, config))
+
+	values, err := nft.ResetCounters(ctx, nft.ResetScope{Family: nft.FamilyIP, Table: table.Name, Counter: "mycounter"})
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, "mycounter", values[0].Name)
+	assert.Equal(t, schema.Counter{Packets: 5, Bytes: 500}, values[0].Counter)
+
+	_, err = nft.ResetCounters(ctx, nft.ResetScope{Family: nft.FamilyIP})
+	assert.Error(t, err)
+}
+
+func testResetTableCounters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	config := nft.NewConfig()
+	config.AddTable(table)
+	config.AddChain(chain)
+	config.Nftables = append(config.Nftables, schema.Nftable{Add: &schema.Objects{Counter: &schema.NamedCounter{
+		Family: table.Family, Table: table.Name, Name: "mycounter", Packets: 5, Bytes: 500,
+	}}})
+	counter := schema.Statement{Counter: &schema.Counter{Packets: 10, Bytes: 1000}}
+	config.AddRule(nft.NewRule(table, chain, []schema.Statement{counter}, nil, nil, "counted"))
+	assert.NoError(t, nft.ApplyConfigContext(ctx, config))
+
+	values, err := nft.ResetCounters(ctx, nft.ResetScope{Family: nft.FamilyIP, Table: table.Name})
+	assert.NoError(t, err)
+	assert.Len(t, values, 2, "Expecting the named counter and the rule counter")
+	for _, value := range values {
+		assert.NotEqual(t, schema.Counter{}, value.Counter)
+	}
+}
+
+func testResetNamedQuotas(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	config := nft.NewConfig()
+	config.AddTable(table)
+	config.Nftables = append(config.Nftables, schema.Nftable{Add: &schema.Objects{Quota: &schema.NamedQuota{
+		Family: table.Family, Table: table.Name, Name: "myquota", Bytes: 1000, Used: 100,
+	}}})
+	assert.NoError(t, nft.ApplyConfigContext(ctx, config))
+
+	scope := nft.ResetScope{Family: nft.FamilyIP, Table: table.Name}
+	values, err := nft.ResetQuotas(ctx, scope)
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, "myquota", values[0].Name)
+	assert.Equal(t, 1000, values[0].Bytes)
+	assert.Equal(t, 100, values[0].Used)
+
+	values, err = nft.ResetQuotas(ctx, nft.ResetScope{Family: nft.FamilyIP, Table: table.Name, Quota: "myquota"})
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	assert.Equal(t, 0, values[0].Used)
+}
@@ -0,0 +1,163 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/tests/testlib"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestSnapshot(t *testing.T) {
+	for _, scope := range []nft.RestoreScope{nft.RestoreRuleset, nft.RestoreTables, nft.RestoreChains} {
+		testlib.RunTestWithFlushTable(t, func(t *testing.T) {
+			testSnapshotRestore(t, scope)
+		})
+	}
+}
+
+func testSnapshotRestore(t *testing.T, scope nft.RestoreScope) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	set := &schema.Set{
+		Family: table.Family, Table: table.Name, Name: "allowed", Type: schema.SetType{"ipv4_addr"},
+		Elem: []schema.Expression{schema.NewString("10.0.0.1")},
+	}
+	config := nft.NewConfig()
+	config.AddTable(table)
+	config.AddChain(chain)
+	config.AddRule(nft.NewRule(table, chain, []schema.Statement{{Counter: &schema.Counter{}}}, nil, nil, "test"))
+	config.AddRule(nft.NewRule(table, chain, []schema.Statement{{Match: &schema.Match{
+		Op:    schema.OperEQ,
+		Left:  schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolIP4, Field: schema.PayloadFieldIPSAddr}},
+		Right: schema.NewString(schema.NewSetReference(set.Name)),
+	}}}, nil, nil, "allowed"))
+	config.AddRule(nft.NewRule(table, chain, []schema.Statement{
+		{RowData: json.RawMessage(`{"log":{"prefix":"mychain "}}`)},
+		{Verdict: schema.Drop()},
+	}, nil, nil, "unmodeled statement"))
+
+	setupConfig := nft.NewConfig()
+	setupConfig.AddTable(table)
+	setupConfig.Nftables = append(setupConfig.Nftables, schema.Nftable{Add: &schema.Objects{Set: set}})
+	setupConfig.Nftables = append(setupConfig.Nftables, config.Nftables[1:]...)
+	assert.NoError(t, nft.ApplyConfigContext(ctx, setupConfig))
+
+	var filter []string
+	if scope != nft.RestoreRuleset {
+		filter = []string{"table", table.Family, table.Name}
+	}
+	snapshot, err := nft.Snapshot(ctx, filter...)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, snapshot.Version)
+
+	serializedSnapshot, err := snapshot.ToJSON()
+	assert.NoError(t, err)
+
+	changeConfig := nft.NewConfig()
+	changeConfig.AddRule(nft.NewRule(table, chain, []schema.Statement{{Verdict: schema.Drop()}}, nil, nil, "change"))
+	assert.NoError(t, nft.ApplyConfigContext(ctx, changeConfig))
+
+	restoredSnapshot := &nft.RulesetSnapshot{}
+	assert.NoError(t, restoredSnapshot.FromJSON(serializedSnapshot))
+	assert.NoError(t, nft.Restore(ctx, restoredSnapshot, scope))
+
+	actualConfig, err := nft.ReadConfigContext(ctx)
+	assert.NoError(t, err)
+	expected, err := testlib.NormalizeConfigForComparison(config).ToJSON()
+	assert.NoError(t, err)
+	actual, err := testlib.NormalizeConfigForComparison(tablesChainsAndRules(actualConfig)).ToJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(actual))
+
+	actualSet, err := nft.ReadSet(ctx, nft.FamilyIP, table.Name, set.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, set.Elem, actualSet.Elem)
+}
+
+func TestRestoreConfig(t *testing.T) {
+	handle := 7
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	set := &schema.Set{Family: table.Family, Table: table.Name, Name: "allowed", Type: schema.SetType{"ipv4_addr"}, Handle: &handle}
+	rule := nft.NewRule(table, chain, []schema.Statement{{Match: &schema.Match{
+		Op:    schema.OperEQ,
+		Left:  schema.Expression{Payload: &schema.Payload{Protocol: schema.PayloadProtocolIP4, Field: schema.PayloadFieldIPSAddr}},
+		Right: schema.NewString(schema.NewSetReference(set.Name)),
+	}}, {RowData: json.RawMessage(`{"log":{"prefix":"allowed "}}`)}}, &handle, nil, "")
+
+	snapshotConfig := nft.NewConfig()
+	snapshotConfig.AddTable(table)
+	snapshotConfig.AddChain(chain)
+	snapshotConfig.AddRule(rule)
+	snapshotConfig.Nftables = append(snapshotConfig.Nftables, schema.Nftable{Set: set})
+
+	t.Run("Restore the sets before the rules referencing them", func(t *testing.T) {
+		snapshot := &nft.RulesetSnapshot{Filter: []string{"table", "ip", table.Name}, Config: snapshotConfig}
+		config, err := nft.RestoreConfig(snapshot, nft.RestoreTables)
+		assert.NoError(t, err)
+
+		addOnly := config.Nftables[2:]
+		assert.Len(t, addOnly, 4)
+		assert.Equal(t, table, addOnly[0].Table)
+		assert.Equal(t, chain, addOnly[1].Chain)
+		assert.NotNil(t, addOnly[2].Set)
+		assert.Nil(t, addOnly[2].Set.Handle)
+		assert.Equal(t, set.Name, addOnly[2].Set.Name)
+		assert.NotNil(t, addOnly[3].Rule)
+		assert.Nil(t, addOnly[3].Rule.Handle)
+		assert.Equal(t, rule.Expr, addOnly[3].Rule.Expr, "the unmodeled statements are kept")
+
+		assert.NotNil(t, set.Handle, "the snapshot is not modified")
+	})
+
+	t.Run("Restoring the ruleset requires an unfiltered snapshot", func(t *testing.T) {
+		snapshot := &nft.RulesetSnapshot{Filter: []string{"table", "ip", table.Name}, Config: snapshotConfig}
+		_, err := nft.RestoreConfig(snapshot, nft.RestoreRuleset)
+		assert.Error(t, err)
+
+		snapshot.Filter = nil
+		config, err := nft.RestoreConfig(snapshot, nft.RestoreRuleset)
+		assert.NoError(t, err)
+		assert.True(t, config.Nftables[0].Flush.Ruleset)
+	})
+}
+
+// tablesChainsAndRules returns the tables, chains and rules entries of the configuration.
+func tablesChainsAndRules(config *nft.Config) *nft.Config {
+	filtered := nft.NewConfig()
+	for _, nftable := range config.Nftables {
+		if nftable.Metainfo != nil || nftable.Table != nil || nftable.Chain != nil || nftable.Rule != nil {
+			filtered.Nftables = append(filtered.Nftables, nftable)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,125 @@
+/*
+ * This file is part of the go-nft project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2021 Red Hat, Inc.
+ *
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/networkplumbing/go-nft/tests/testlib"
+
+	"github.com/networkplumbing/go-nft/nft"
+	"github.com/networkplumbing/go-nft/nft/schema"
+)
+
+func TestTransaction(t *testing.T) {
+	testlib.RunTestWithFlushTable(t, testTransactionCommit)
+	testlib.RunTestWithFlushTable(t, testTransactionFailureRestoresSnapshot)
+	testlib.RunTestWithFlushTable(t, testTransactionRollback)
+}
+
+func testTransactionCommit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	tx, err := nft.NewTransaction(ctx, table)
+	assert.NoError(t, err)
+
+	tableConfig := nft.NewConfig()
+	tableConfig.AddTable(table)
+	tx.Add("add table", tableConfig)
+
+	chainConfig := nft.NewConfig()
+	chainConfig.AddChain(nft.NewRegularChain(table, "mychain"))
+	tx.Add("add chain", chainConfig)
+
+	assert.NoError(t, tx.Commit(ctx))
+
+	config, err := nft.ReadConfigContext(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, config.LookupChain(nft.NewRegularChain(table, "mychain")))
+}
+
+func testTransactionFailureRestoresSnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	chain := nft.NewRegularChain(table, "mychain")
+	initialConfig := nft.NewConfig()
+	initialConfig.AddTable(table)
+	initialConfig.AddChain(chain)
+	initialConfig.AddRule(nft.NewRule(table, chain, []schema.Statement{{Counter: &schema.Counter{}}}, nil, nil, "test"))
+	assert.NoError(t, nft.ApplyConfigContext(ctx, initialConfig))
+
+	tx, err := nft.NewTransaction(ctx, table)
+	assert.NoError(t, err)
+
+	flushConfig := nft.NewConfig()
+	flushConfig.FlushChain(chain)
+	tx.Add("flush chain", flushConfig)
+
+	deleteConfig := nft.NewConfig()
+	deleteConfig.DeleteChain(nft.NewRegularChain(table, "missing-chain"))
+	tx.Add("delete missing chain", deleteConfig)
+
+	err = tx.Commit(ctx)
+	var txErr *nft.TransactionError
+	assert.True(t, errors.As(err, &txErr))
+	assert.Equal(t, 1, txErr.Step)
+	assert.Equal(t, "delete missing chain", txErr.Name)
+	assert.NoError(t, txErr.RollbackErr)
+
+	err = tx.Commit(ctx)
+	assert.True(t, errors.As(err, &txErr), "the steps are kept after a failure")
+	assert.Equal(t, 1, txErr.Step)
+
+	config, err := nft.ReadConfigContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		testlib.NormalizeConfigForComparison(initialConfig).Nftables,
+		testlib.NormalizeConfigForComparison(config).Nftables,
+	)
+}
+
+func testTransactionRollback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := nft.NewTable("mytable", nft.FamilyIP)
+	tx, err := nft.NewTransaction(ctx, table)
+	assert.NoError(t, err)
+
+	tableConfig := nft.NewConfig()
+	tableConfig.AddTable(table)
+	tx.Add("add table", tableConfig)
+	assert.NoError(t, tx.Commit(ctx))
+
+	assert.NoError(t, tx.Rollback(ctx))
+
+	config, err := nft.ReadConfigContext(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, config.LookupTable(table))
+}